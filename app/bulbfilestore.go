@@ -2,10 +2,7 @@ package app
 
 import (
 	"cmp"
-	"encoding/json"
 	"errors"
-	"fmt"
-	"os"
 	"path"
 	"slices"
 )
@@ -23,22 +20,7 @@ func NewBulbFileStore(dir string) *BulbFileStore {
 }
 
 func (b *BulbFileStore) Init() error {
-	filePath := b.bulbsPath()
-	bytes, err := os.ReadFile(filePath)
-	if err != nil {
-		if errors.Is(err, os.ErrNotExist) {
-			return nil
-		}
-
-		return fmt.Errorf("read data from %q: %w", filePath, err)
-	}
-
-	err = json.Unmarshal(bytes, &b.bulbs)
-	if err != nil {
-		return fmt.Errorf("decode data from %q: %w", filePath, err)
-	}
-
-	return nil
+	return readJSONFile(b.bulbsPath(), &b.bulbs)
 }
 
 func (b *BulbFileStore) All() []Bulb {
@@ -92,17 +74,7 @@ func (b *BulbFileStore) Delete(bulb Bulb) {
 }
 
 func (b *BulbFileStore) Flush() error {
-	bytes, err := json.Marshal(b.bulbs)
-	if err != nil {
-		return fmt.Errorf("encode data: %w", err)
-	}
-
-	filePath := b.bulbsPath()
-	if err := os.WriteFile(filePath, bytes, 0o600); err != nil {
-		return fmt.Errorf("save data to %q: %w", filePath, err)
-	}
-
-	return nil
+	return writeJSONFile(b.bulbsPath(), b.bulbs)
 }
 
 func (b *BulbFileStore) bulbsPath() string {
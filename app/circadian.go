@@ -0,0 +1,343 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pugkong/ylc/yeelight"
+)
+
+type CurvePoint struct {
+	At               string `json:"at"`
+	ColorTemperature int    `json:"ct"`
+	Bright           int    `json:"bright"`
+}
+
+// Curve sets color temperature and brightness at times of the day. Values
+// between the points are interpolated linearly, wrapping around midnight.
+type Curve []CurvePoint
+
+var DefaultCurve = Curve{
+	{At: "00:00", ColorTemperature: 1900, Bright: 20},
+	{At: "06:00", ColorTemperature: 2200, Bright: 40},
+	{At: "08:00", ColorTemperature: 4000, Bright: 80},
+	{At: "12:00", ColorTemperature: 6500, Bright: 100},
+	{At: "17:00", ColorTemperature: 5000, Bright: 100},
+	{At: "20:00", ColorTemperature: 2700, Bright: 70},
+	{At: "22:00", ColorTemperature: 2000, Bright: 40},
+}
+
+var (
+	ErrInvalidCurve             = errors.New("invalid circadian curve")
+	ErrInvalidCircadianInterval = errors.New("circadian interval must be positive")
+	ErrInvalidCircadianPause    = errors.New("circadian pause must not be negative")
+)
+
+type curvePoint struct {
+	minute           int
+	colorTemperature int
+	bright           int
+}
+
+func (c Curve) parse() ([]curvePoint, error) {
+	if len(c) == 0 {
+		return nil, fmt.Errorf("%w: no points", ErrInvalidCurve)
+	}
+
+	points := make([]curvePoint, 0, len(c))
+	for _, point := range c {
+		at, err := time.Parse("15:04", point.At)
+		if err != nil {
+			return nil, fmt.Errorf("%w: time %q", ErrInvalidCurve, point.At)
+		}
+
+		if point.ColorTemperature < yeelight.MinColorTemperature || point.ColorTemperature > yeelight.MaxColorTemperature {
+			return nil, fmt.Errorf("%w: color temperature %d at %s is not in %d-%d", ErrInvalidCurve,
+				point.ColorTemperature, point.At, yeelight.MinColorTemperature, yeelight.MaxColorTemperature)
+		}
+
+		if point.Bright < yeelight.MinBright || point.Bright > yeelight.MaxBright {
+			return nil, fmt.Errorf("%w: brightness %d at %s is not in %d-%d", ErrInvalidCurve,
+				point.Bright, point.At, yeelight.MinBright, yeelight.MaxBright)
+		}
+
+		points = append(points, curvePoint{
+			minute:           at.Hour()*60 + at.Minute(),
+			colorTemperature: point.ColorTemperature,
+			bright:           point.Bright,
+		})
+	}
+
+	slices.SortFunc(points, func(a, b curvePoint) int { return a.minute - b.minute })
+
+	return points, nil
+}
+
+const minutesPerDay = 24 * 60
+
+func curveAt(points []curvePoint, t time.Time) (colorTemperature, bright int) {
+	minute := float64(t.Hour()*60+t.Minute()) + float64(t.Second())/60
+
+	prev, next := points[len(points)-1], points[0]
+	prevMinute, nextMinute := float64(prev.minute-minutesPerDay), float64(next.minute)
+	for i, point := range points {
+		if float64(point.minute) > minute {
+			break
+		}
+
+		prev, prevMinute = point, float64(point.minute)
+		next, nextMinute = points[0], float64(points[0].minute+minutesPerDay)
+		if i+1 < len(points) {
+			next, nextMinute = points[i+1], float64(points[i+1].minute)
+		}
+	}
+
+	share := 0.0
+	if nextMinute > prevMinute {
+		share = (minute - prevMinute) / (nextMinute - prevMinute)
+	}
+
+	interpolate := func(from, to int) int {
+		return from + int(float64(to-from)*share+0.5)
+	}
+
+	return interpolate(prev.colorTemperature, next.colorTemperature), interpolate(prev.bright, next.bright)
+}
+
+type CircadianConfig struct {
+	Curve    Curve    `json:"curve"`
+	Interval Duration `json:"interval"`
+	Pause    Duration `json:"pause"`
+}
+
+var DefaultCircadianConfig = CircadianConfig{
+	Curve:    DefaultCurve,
+	Interval: Duration(5 * time.Minute),
+	Pause:    Duration(time.Hour),
+}
+
+type Circadian struct {
+	store    *BulbFileStore
+	dialer   *Dialer
+	control  *Control
+	points   []curvePoint
+	interval time.Duration
+	pause    time.Duration
+	bright   bool
+	printer  Printer
+	now      func() time.Time
+
+	mu     sync.Mutex
+	states map[string]*circadianState
+	wake   chan string
+}
+
+// circadianState is what the bulb was last set to, to tell own changes from
+// manual ones in notifications.
+type circadianState struct {
+	colorTemperature int
+	bright           int
+	pausedUntil      time.Time
+}
+
+func NewCircadian(
+	store *BulbFileStore,
+	dialer *Dialer,
+	config CircadianConfig,
+	bright bool,
+	printer Printer,
+) (*Circadian, error) {
+	if config.Interval <= 0 {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidCircadianInterval, time.Duration(config.Interval))
+	}
+
+	if config.Pause < 0 {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidCircadianPause, time.Duration(config.Pause))
+	}
+
+	points, err := config.Curve.parse()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Circadian{
+		store:    store,
+		dialer:   dialer,
+		control:  NewControl(store, dialer, printer),
+		points:   points,
+		interval: time.Duration(config.Interval),
+		pause:    time.Duration(config.Pause),
+		bright:   bright,
+		printer:  printer,
+		now:      time.Now,
+		states:   make(map[string]*circadianState),
+	}, nil
+}
+
+// Apply sets bulbs to the current point of the curve once, over the interval.
+func (c *Circadian) Apply(names []string) error {
+	names, err := c.bulbNames(names)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, name := range names {
+		errs = append(errs, c.update(name, c.interval))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Run follows the curve until the context is done. A bulb changed by someone
+// else is left alone for the pause, a bulb turned on is updated right away.
+func (c *Circadian) Run(ctx context.Context, names []string) error {
+	names, err := c.bulbNames(names)
+	if err != nil {
+		return err
+	}
+
+	c.wake = make(chan string, len(names))
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for _, name := range names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			watchBulb(ctx, c.store, c.dialer, name, nil, func(props map[string]string) {
+				c.notified(name, props)
+			})
+		}()
+	}
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	c.updateAll(names)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			c.updateAll(names)
+		case name := <-c.wake:
+			c.report(name, c.update(name, time.Second))
+		}
+	}
+}
+
+func (c *Circadian) bulbNames(names []string) ([]string, error) {
+	if len(names) == 0 {
+		return c.store.AllNames(), nil
+	}
+
+	for _, name := range names {
+		if _, err := c.store.FindByName(name); err != nil {
+			return nil, fmt.Errorf("find %q bulb: %w", name, err)
+		}
+	}
+
+	return names, nil
+}
+
+func (c *Circadian) updateAll(names []string) {
+	for _, name := range names {
+		c.report(name, c.update(name, c.interval))
+	}
+}
+
+func (c *Circadian) report(name string, err error) {
+	if err != nil {
+		c.printer.Printf("Update %q bulb failed: %v\n", name, err)
+	}
+}
+
+func (c *Circadian) update(name string, duration time.Duration) error {
+	now := c.now()
+	colorTemperature, bright := curveAt(c.points, now)
+
+	c.mu.Lock()
+	state := c.state(name)
+	if now.Before(state.pausedUntil) {
+		slog.Debug("skip paused bulb", "bulb", name, "until", state.pausedUntil)
+		c.mu.Unlock()
+
+		return nil
+	}
+	state.colorTemperature = colorTemperature
+	if c.bright {
+		state.bright = bright
+	}
+	c.mu.Unlock()
+
+	slog.Debug("follow circadian curve", "bulb", name, "ct", colorTemperature, "bright", bright)
+
+	ms := int(duration.Milliseconds())
+	err := c.control.SetTemperature(name, LightsMain, colorTemperature, false, yeelight.EffectSmooth, ms)
+	if err == nil && c.bright {
+		err = c.control.SetBright(name, LightsMain, bright, false, yeelight.EffectSmooth, ms)
+	}
+
+	if errors.Is(err, yeelight.ErrNotPoweredOn) {
+		slog.Debug("skip bulb which is off", "bulb", name)
+
+		return nil
+	}
+
+	return err
+}
+
+func (c *Circadian) state(name string) *circadianState {
+	state, ok := c.states[name]
+	if !ok {
+		state = &circadianState{}
+		c.states[name] = state
+	}
+
+	return state
+}
+
+func (c *Circadian) notified(name string, props map[string]string) {
+	now := c.now()
+
+	c.mu.Lock()
+	state := c.state(name)
+	manual := state.colorTemperature != 0 && (changedProp(props, "ct", state.colorTemperature) ||
+		props["color_mode"] != "" && props["color_mode"] != yeelight.ColorModeTemperature ||
+		c.bright && changedProp(props, "bright", state.bright))
+	if manual {
+		state.pausedUntil = now.Add(c.pause)
+	}
+	pausedUntil := state.pausedUntil
+	c.mu.Unlock()
+
+	if manual {
+		c.printer.Printf("Pause %q bulb until %s after a manual change\n", name, pausedUntil.Format("15:04"))
+	}
+
+	if props["power"] == "on" && !now.Before(pausedUntil) {
+		select {
+		case c.wake <- name:
+		default:
+		}
+	}
+}
+
+func changedProp(props map[string]string, name string, expected int) bool {
+	value, ok := props[name]
+	if !ok {
+		return false
+	}
+
+	actual, err := strconv.Atoi(value)
+
+	return err != nil || actual != expected
+}
@@ -0,0 +1,36 @@
+package app
+
+import (
+	"path"
+	"slices"
+)
+
+type Config struct {
+	PowerOn bool        `json:"power_on"`
+	Timeout Duration    `json:"timeout"`
+	Retry   RetryPolicy `json:"retry"`
+
+	Location  *Location       `json:"location"`
+	Circadian CircadianConfig `json:"circadian"`
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Timeout: Duration(DefaultTimeout),
+		Retry:   DefaultRetryPolicy,
+		Circadian: CircadianConfig{
+			Curve:    slices.Clone(DefaultCircadianConfig.Curve),
+			Interval: DefaultCircadianConfig.Interval,
+			Pause:    DefaultCircadianConfig.Pause,
+		},
+	}
+}
+
+func LoadConfig(dir string) (Config, error) {
+	config := DefaultConfig()
+	if err := readJSONFile(path.Join(dir, "config.json"), &config); err != nil {
+		return Config{}, err
+	}
+
+	return config, nil
+}
@@ -1,216 +1,554 @@
 package app
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
-	"net"
+	"log/slog"
+	"math"
+	"strings"
+	"time"
 
 	"github.com/pugkong/ylc/yeelight"
 )
 
 type Control struct {
 	store   *BulbFileStore
+	dialer  *Dialer
 	printer Printer
 }
 
-func NewControl(store *BulbFileStore, printer Printer) *Control {
-	return &Control{store: store, printer: printer}
+func NewControl(store *BulbFileStore, dialer *Dialer, printer Printer) *Control {
+	return &Control{store: store, dialer: dialer, printer: printer}
 }
 
-func (c *Control) Info(name string) (err error) {
-	conn, connClose, err := c.connectByName(name)
+func (c *Control) controller(conn Conn) *yeelight.Controller {
+	controller := yeelight.NewController(conn)
+	controller.Clamp = c.dialer != nil && c.dialer.Clamp
+
+	return controller
+}
+
+func (c *Control) Info(name string) error {
+	info, err := c.info(name)
 	if err != nil {
 		return err
 	}
-	defer func() { err = errors.Join(err, connClose()) }()
 
-	info, err := yeelight.NewController(conn).Info()
+	c.printInfo(info)
+
+	return nil
+}
+
+func (c *Control) InfoJSON(name string) error {
+	info, err := c.info(name)
 	if err != nil {
-		return fmt.Errorf("query %q bulb info: %w", name, err)
+		return err
 	}
 
-	c.printInfo(info)
+	data, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode %q bulb info: %w", name, err)
+	}
+
+	c.printer.Println(string(data))
 
 	return nil
 }
 
-func (c *Control) printInfo(info yeelight.BulbInfo) {
-	c.printer.Printf("Power: %s\n", info.Power)
-	c.printer.Printf("Bright: %s\n", info.Bright)
+type Info struct {
+	BulbState
 
-	switch info.ColorMode {
-	case yeelight.ColorModeRGB:
-		c.printer.Println("Color mode: RGB")
+	DelayOff int `json:"delay_off,omitempty"`
+}
 
-		rgb, err := info.RGB.Int()
-		if err != nil {
-			c.printer.Printf("Invalid RGB value: %s", info.RGB)
-		} else {
-			c.printer.Printf("RGB: %06x\n", rgb)
-		}
-	case yeelight.ColorModeTemperature:
-		c.printer.Println("Color mode: temperature")
-		c.printer.Printf("Color temperature: %s\n", info.ColorTemperature)
-	case yeelight.ColorModeHSV:
-		c.printer.Println("Color mode: HSV")
-		c.printer.Printf("HUE: %s\n", info.HUE)
-		c.printer.Printf("Saturation: %s\n", info.Saturation)
+func (c *Control) info(name string) (info Info, err error) {
+	conn, connClose, err := c.connectByName(name)
+	if err != nil {
+		return Info{}, err
 	}
+	defer func() { err = errors.Join(err, connClose()) }()
 
-	c.printer.Println()
+	rawInfo, err := c.controller(conn).Info()
+	if err != nil {
+		return Info{}, fmt.Errorf("query %q bulb info: %w", name, withHint(err))
+	}
 
-	c.printer.Printf("Background power: %s\n", info.BackgroundPower)
-	c.printer.Printf("Background bright: %s\n", info.BackgroundBright)
+	info, err = infoFromRaw(rawInfo)
+	if err != nil {
+		return Info{}, fmt.Errorf("read %q bulb info: %w", name, err)
+	}
 
-	switch info.BackgroundColorMode {
-	case yeelight.ColorModeRGB:
-		c.printer.Println("Background color mode: RGB")
+	return info, nil
+}
 
-		rgb, err := info.BackgroundRGB.Int()
-		if err != nil {
-			c.printer.Printf("Invalid background RGB value: %s", info.BackgroundRGB)
-		} else {
-			c.printer.Printf("Background RGB: %06x\n", rgb)
-		}
-	case yeelight.ColorModeTemperature:
-		c.printer.Println("Background color mode: temperature")
-		c.printer.Printf("Background color temperature: %s\n", info.BackgroundColorTemperature)
-	case yeelight.ColorModeHSV:
-		c.printer.Println("Background color mode: HSV")
-		c.printer.Printf("Background HUE: %s\n", info.BackgroundHUE)
-		c.printer.Printf("Background saturation: %s\n", info.BackgroundSaturation)
+func infoFromRaw(rawInfo yeelight.BulbInfo) (Info, error) {
+	state, err := stateFromInfo(rawInfo)
+	if err != nil {
+		return Info{}, err
+	}
+
+	info := Info{BulbState: state}
+
+	info.DelayOff, err = optionalAtoi(rawInfo.DelayOff, "delay off")
+	if err != nil {
+		return Info{}, err
+	}
+
+	return info, nil
+}
+
+func (c *Control) State(name string) (BulbState, error) {
+	info, err := c.info(name)
+	if err != nil {
+		return BulbState{}, err
 	}
+
+	return info.BulbState, nil
 }
 
-func (c *Control) PowerToggle(name string) (err error) {
+func (c *Control) ApplyState(name string, state BulbState, effect yeelight.Effect, duration int) (err error) {
 	conn, connClose, err := c.connectByName(name)
 	if err != nil {
 		return err
 	}
 	defer func() { err = errors.Join(err, connClose()) }()
 
-	if err := yeelight.NewController(conn).PowerToggle(); err != nil {
-		return fmt.Errorf("toggle %q bulb power: %w", name, err)
+	if err := applyState(c.controller(conn), state, effect, duration); err != nil {
+		return fmt.Errorf("apply %q bulb state: %w", name, withHint(err))
 	}
 
 	return nil
 }
 
-func (c *Control) BackgroundToggle(name string) (err error) {
+func (c *Control) printInfo(info Info) {
+	c.printLight("", info.Main)
+
+	if info.Mode != "" {
+		c.printer.Printf("Mode: %s\n", info.Mode)
+	}
+
+	if info.Mode == ModeMoonlight {
+		c.printer.Printf("Night light bright: %d\n", info.NightLightBright)
+	}
+
+	c.printColor("", info.Main)
+
+	if info.Background != nil {
+		c.printer.Println()
+
+		c.printLight("Background ", *info.Background)
+		c.printColor("Background ", *info.Background)
+	}
+
+	if info.DelayOff > 0 {
+		c.printer.Println()
+		c.printer.Printf("Power off in: %s\n", time.Duration(info.DelayOff)*time.Minute)
+	}
+}
+
+func (c *Control) printLight(label string, state LightState) {
+	c.printer.Printf("%s: %s\n", infoField(label, "power"), state.Power)
+	c.printer.Printf("%s: %d\n", infoField(label, "bright"), state.Bright)
+}
+
+func (c *Control) printColor(label string, state LightState) {
+	switch state.ColorMode {
+	case ColorModeRGB:
+		c.printer.Printf("%s: RGB\n", infoField(label, "color mode"))
+		c.printer.Printf("%s: %06x\n", infoField(label, "RGB"), state.RGB)
+	case ColorModeTemperature:
+		c.printer.Printf("%s: temperature\n", infoField(label, "color mode"))
+		c.printer.Printf("%s: %d\n", infoField(label, "color temperature"), state.ColorTemperature)
+	case ColorModeHSV:
+		c.printer.Printf("%s: HSV\n", infoField(label, "color mode"))
+		c.printer.Printf("%s: %d\n", infoField(label, "HUE"), state.HUE)
+		c.printer.Printf("%s: %d\n", infoField(label, "saturation"), state.Saturation)
+	}
+}
+
+func infoField(label, name string) string {
+	if label == "" {
+		return strings.ToUpper(name[:1]) + name[1:]
+	}
+
+	return label + name
+}
+
+func (c *Control) PowerToggle(name string, lights Lights) (err error) {
+	if lights != LightsBoth {
+		return c.control(name, lights, "toggle", "power", func(l light) error { return l.toggle() })
+	}
+
 	conn, connClose, err := c.connectByName(name)
 	if err != nil {
 		return err
 	}
 	defer func() { err = errors.Join(err, connClose()) }()
 
-	if err := yeelight.NewController(conn).BackgroundToggle(); err != nil {
-		return fmt.Errorf("toggle %q bulb background power: %w", name, err)
+	if err := c.controller(conn).PowerToggle(); err != nil {
+		return fmt.Errorf("toggle %q bulb power: %w", name, withHint(err))
 	}
 
 	return nil
 }
 
-func (c *Control) SetBright(name string, value int, effect yeelight.Effect, duration int) (err error) {
+func (c *Control) SetBright(name string, lights Lights, value int, on bool, effect yeelight.Effect, duration int) error {
+	return c.controlPoweredOn(name, lights, on, yeelight.PowerModeNormal, effect, duration, "set", "bright",
+		func(l light) error {
+			return l.bright(value, effect, duration)
+		},
+	)
+}
+
+func (c *Control) AdjustBright(name string, lights Lights, percentage int, on bool, duration int) error {
+	return c.controlPoweredOn(name, lights, on, yeelight.PowerModeNormal, yeelight.EffectSmooth, duration,
+		"adjust", "bright",
+		func(l light) error {
+			return l.adjustBright(percentage, duration)
+		},
+	)
+}
+
+func (c *Control) SetTemperature(
+	name string,
+	lights Lights,
+	value int,
+	on bool,
+	effect yeelight.Effect,
+	duration int,
+) error {
+	return c.controlPoweredOn(name, lights, on, yeelight.PowerModeTemperature, effect, duration, "set", "temperature",
+		func(l light) error {
+			return l.colorTemperature(value, effect, duration)
+		},
+	)
+}
+
+func (c *Control) AdjustTemperature(name string, lights Lights, percentage int, on bool, duration int) error {
+	return c.controlPoweredOn(name, lights, on, yeelight.PowerModeTemperature, yeelight.EffectSmooth, duration,
+		"adjust", "temperature",
+		func(l light) error {
+			return l.adjustColorTemperature(percentage, duration)
+		},
+	)
+}
+
+func (c *Control) ShiftTemperature(
+	name string,
+	lights Lights,
+	delta int,
+	on bool,
+	effect yeelight.Effect,
+	duration int,
+) error {
+	var state BulbState
+
+	return c.controlWithState(name, lights, &state, "shift", "temperature", func(l light) error {
+		lightState := l.state(state)
+		if lightState == nil {
+			return ErrNoLight
+		}
+
+		if on {
+			if err := powerOn(l, *lightState, yeelight.PowerModeTemperature, effect, duration); err != nil {
+				return err
+			}
+		}
+
+		value := min(
+			max(lightState.ColorTemperature+delta, yeelight.MinColorTemperature),
+			yeelight.MaxColorTemperature,
+		)
+
+		return l.colorTemperature(value, effect, duration)
+	})
+}
+
+func (c *Control) SetRGB(name string, lights Lights, value int, on bool, effect yeelight.Effect, duration int) error {
+	return c.controlPoweredOn(name, lights, on, yeelight.PowerModeRGB, effect, duration, "set", "rgb color",
+		func(l light) error {
+			return l.rgb(value, effect, duration)
+		},
+	)
+}
+
+func (c *Control) SaveDefault(name string, lights Lights) error {
+	return c.control(name, lights, "save", "default", func(l light) error {
+		return l.saveDefault()
+	})
+}
+
+var ErrNoLight = errors.New("bulb has no such light")
+
+type hintError struct {
+	hint string
+	err  error
+}
+
+func (e *hintError) Error() string {
+	return e.hint + ": " + e.err.Error()
+}
+
+func (e *hintError) Unwrap() error {
+	return e.err
+}
+
+// withHint puts a message telling what to do before errors reported by a
+// bulb. The bulb error stays in the message and in the chain for errors.Is.
+func withHint(err error) error {
+	var bulbErr *yeelight.BulbError
+	if !errors.As(err, &bulbErr) {
+		return err
+	}
+
+	var hint string
+	switch {
+	case errors.Is(err, yeelight.ErrNotPoweredOn):
+		hint = "bulb is off; use --on to power it on first"
+	case errors.Is(err, yeelight.ErrMethodNotSupported):
+		hint = "bulb does not support this command"
+	case errors.Is(err, yeelight.ErrInvalidParams):
+		hint = "bulb rejected the values; check they are in range"
+	case errors.Is(err, yeelight.ErrQuotaExceeded):
+		hint = "bulb command quota exceeded; retry in a minute or use --wait"
+	default:
+		return err
+	}
+
+	return &hintError{hint: hint, err: err}
+}
+
+func (c *Control) control(name string, lights Lights, verb, subject string, fn func(light) error) error {
+	return c.controlWithState(name, lights, nil, verb, subject, fn)
+}
+
+// controlPoweredOn powers lights that are off on in the given mode right
+// before fn changes them, so both transitions run together.
+func (c *Control) controlPoweredOn(
+	name string,
+	lights Lights,
+	on bool,
+	mode yeelight.PowerMode,
+	effect yeelight.Effect,
+	duration int,
+	verb, subject string,
+	fn func(light) error,
+) error {
+	if !on {
+		return c.control(name, lights, verb, subject, fn)
+	}
+
+	var state BulbState
+
+	return c.controlWithState(name, lights, &state, verb, subject, func(l light) error {
+		lightState := l.state(state)
+		if lightState == nil {
+			return ErrNoLight
+		}
+
+		if err := powerOn(l, *lightState, mode, effect, duration); err != nil {
+			return err
+		}
+
+		return fn(l)
+	})
+}
+
+func powerOn(l light, state LightState, mode yeelight.PowerMode, effect yeelight.Effect, duration int) error {
+	if state.Power == yeelight.PowerOn {
+		return nil
+	}
+
+	if err := l.power(yeelight.PowerOn, effect, duration, mode); err != nil {
+		return fmt.Errorf("power on: %w", err)
+	}
+
+	return nil
+}
+
+func (c *Control) controlWithState(
+	name string,
+	lights Lights,
+	state *BulbState,
+	verb, subject string,
+	fn func(light) error,
+) (err error) {
 	conn, connClose, err := c.connectByName(name)
 	if err != nil {
 		return err
 	}
 	defer func() { err = errors.Join(err, connClose()) }()
 
-	if err := yeelight.NewController(conn).Bright(value, effect, duration); err != nil {
-		return fmt.Errorf("set %q bulb bright: %w", name, err)
+	controller := c.controller(conn)
+
+	// Background commands are checked before anything is sent, so a bulb
+	// without the light does not change its main light for --light both.
+	checkBackground := lights != LightsMain && !c.dialer.dryRun()
+	if state != nil || checkBackground {
+		info, err := controller.Info()
+		if err != nil {
+			return fmt.Errorf("query %q bulb info: %w", name, withHint(err))
+		}
+
+		if checkBackground && !info.HasBackground() {
+			return fmt.Errorf("%s %q bulb background %s: %w", verb, name, subject,
+				ErrNoLight)
+		}
+
+		if state != nil {
+			*state, err = stateFromInfo(info)
+			if err != nil {
+				return fmt.Errorf("read %q bulb state: %w", name, err)
+			}
+		}
+	}
+
+	for _, l := range selectLights(controller, lights) {
+		if err := fn(l); err != nil {
+			return fmt.Errorf("%s %q bulb %s%s: %w", verb, name, l.label, subject, withHint(err))
+		}
 	}
 
 	return nil
 }
 
-func (c *Control) SetBackgroundBright(name string, value int, effect yeelight.Effect, duration int) (err error) {
+func (c *Control) SetTimer(name string, delay time.Duration) (err error) {
 	conn, connClose, err := c.connectByName(name)
 	if err != nil {
 		return err
 	}
 	defer func() { err = errors.Join(err, connClose()) }()
 
-	if err := yeelight.NewController(conn).BackgroundBright(value, effect, duration); err != nil {
-		return fmt.Errorf("set %q bulb background bright: %w", name, err)
+	minutes := int(math.Ceil(delay.Minutes()))
+	if err := c.controller(conn).AddCron(yeelight.CronTypePowerOff, minutes); err != nil {
+		return fmt.Errorf("set %q bulb timer: %w", name, withHint(err))
 	}
 
+	c.printer.Printf("Power off in %s\n", time.Duration(minutes)*time.Minute)
+
 	return nil
 }
 
-func (c *Control) SetTemperature(name string, value int, effect yeelight.Effect, duration int) (err error) {
+func (c *Control) CancelTimer(name string) (err error) {
 	conn, connClose, err := c.connectByName(name)
 	if err != nil {
 		return err
 	}
 	defer func() { err = errors.Join(err, connClose()) }()
 
-	if err := yeelight.NewController(conn).ColorTemperature(value, effect, duration); err != nil {
-		return fmt.Errorf("set %q bulb temperature: %w", name, err)
+	if err := c.controller(conn).DeleteCron(yeelight.CronTypePowerOff); err != nil {
+		return fmt.Errorf("cancel %q bulb timer: %w", name, withHint(err))
 	}
 
 	return nil
 }
 
-func (c *Control) SetBackgroundTemperature(name string, value int, effect yeelight.Effect, duration int) (err error) {
+func (c *Control) ShowTimer(name string) (err error) {
 	conn, connClose, err := c.connectByName(name)
 	if err != nil {
 		return err
 	}
 	defer func() { err = errors.Join(err, connClose()) }()
 
-	if err := yeelight.NewController(conn).BackgroundColorTemperature(value, effect, duration); err != nil {
-		return fmt.Errorf("set %q bulb background temperature: %w", name, err)
+	crons, err := c.controller(conn).Crons(yeelight.CronTypePowerOff)
+	if err != nil {
+		return fmt.Errorf("query %q bulb timer: %w", name, withHint(err))
 	}
 
+	for _, cron := range crons {
+		if cron.Type == yeelight.CronTypePowerOff && cron.Delay > 0 {
+			c.printer.Printf("Power off in %s\n", time.Duration(cron.Delay)*time.Minute)
+
+			return nil
+		}
+	}
+
+	c.printer.Println("No timer")
+
 	return nil
 }
 
-func (c *Control) SetRGB(name string, value int, effect yeelight.Effect, duration int) (err error) {
+func (c *Control) NightLightOn(name string, bright int, effect yeelight.Effect, duration int) (err error) {
 	conn, connClose, err := c.connectByName(name)
 	if err != nil {
 		return err
 	}
 	defer func() { err = errors.Join(err, connClose()) }()
 
-	if err := yeelight.NewController(conn).RGB(value, effect, duration); err != nil {
-		return fmt.Errorf("set %q bulb rgb color: %w", name, err)
+	controller := c.controller(conn)
+
+	if err := controller.Power(yeelight.PowerOn, effect, duration, yeelight.PowerModeNightLight); err != nil {
+		return fmt.Errorf("turn on %q bulb night light: %w", name, withHint(err))
+	}
+
+	if bright != 0 {
+		if err := controller.Bright(bright, effect, duration); err != nil {
+			return fmt.Errorf("set %q bulb night light bright: %w", name, withHint(err))
+		}
 	}
 
 	return nil
 }
 
-func (c *Control) SetBackgroundRGB(name string, value int, effect yeelight.Effect, duration int) (err error) {
+func (c *Control) NightLightOff(name string, effect yeelight.Effect, duration int) (err error) {
 	conn, connClose, err := c.connectByName(name)
 	if err != nil {
 		return err
 	}
 	defer func() { err = errors.Join(err, connClose()) }()
 
-	if err := yeelight.NewController(conn).BackgroundRGB(value, effect, duration); err != nil {
-		return fmt.Errorf("set %q bulb background rgb color: %w", name, err)
+	err = c.controller(conn).Power(yeelight.PowerOn, effect, duration, yeelight.PowerModeTemperature)
+	if err != nil {
+		return fmt.Errorf("turn off %q bulb night light: %w", name, withHint(err))
 	}
 
 	return nil
 }
 
-func (c *Control) connectByName(name string) (*net.TCPConn, func() error, error) {
-	bulb, err := c.store.FindByName(name)
+const (
+	ModeDaylight  = "daylight"
+	ModeMoonlight = "moonlight"
+)
+
+func (c *Control) Mode(name string) (string, error) {
+	info, err := c.info(name)
 	if err != nil {
-		return nil, nil, fmt.Errorf("find %q bulb: %w", name, err)
+		return "", err
+	}
+
+	switch {
+	case info.Main.Power != yeelight.PowerOn:
+		return string(yeelight.PowerOff), nil
+	case info.Mode != "":
+		return info.Mode, nil
+	default:
+		return string(yeelight.PowerOn), nil
 	}
+}
 
-	addr, err := net.ResolveTCPAddr("tcp", bulb.Addr)
+func activeMode(info yeelight.BulbInfo) string {
+	if info.ActiveMode == yeelight.ActiveModeMoonlight {
+		return ModeMoonlight
+	}
+
+	return ModeDaylight
+}
+
+func (c *Control) connectByName(name string) (Conn, func() error, error) {
+	bulb, err := c.store.FindByName(name)
 	if err != nil {
-		return nil, nil, fmt.Errorf("resolve addr for %q bulb: %w", name, err)
+		return nil, nil, fmt.Errorf("find %q bulb: %w", name, err)
 	}
 
-	conn, err := net.DialTCP("tcp", nil, addr)
+	conn, err := c.dialer.Dial(bulb)
 	if err != nil {
-		return nil, nil, fmt.Errorf("connect to %q bulb: %w", name, err)
+		return nil, nil, err
 	}
 
 	connClose := func() error {
+		slog.Debug("close bulb connection", "bulb", name)
+
 		if err := conn.Close(); err != nil {
 			return fmt.Errorf("close connection to %q bulb: %w", name, err)
 		}
@@ -0,0 +1,354 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pugkong/ylc/color"
+	"github.com/pugkong/ylc/yeelight"
+)
+
+// Palette is the colors the dashboard can pick from.
+var Palette = []int{0xff0000, 0xff8000, 0xffd000, 0x00ff00, 0x00ffff, 0x0040ff, 0x8000ff, 0xff00a0}
+
+const (
+	dashboardBrightStep      = 10
+	dashboardTemperatureStep = 500
+	dashboardDuration        = 300
+	brightBarWidth           = 10
+)
+
+type dashboardBulb struct {
+	name   string
+	state  LightState
+	err    error
+	loaded bool
+}
+
+// Dashboard shows the main light of every known bulb, kept up to date by
+// notifications, and controls the selected one. Queries and actions run in
+// the background, so a slow bulb does not hold up the screen, and every
+// change is signaled on Changes.
+type Dashboard struct {
+	store   *BulbFileStore
+	dialer  *Dialer
+	control *Control
+	printer Printer
+
+	wg       sync.WaitGroup
+	mu       sync.Mutex
+	bulbs    []*dashboardBulb
+	selected int
+	message  string
+	actions  []func()
+	acting   bool
+	changes  chan struct{}
+}
+
+func NewDashboard(store *BulbFileStore, dialer *Dialer, printer Printer) *Dashboard {
+	return &Dashboard{
+		store:   store,
+		dialer:  dialer,
+		control: NewControl(store, dialer, printer),
+		printer: printer,
+		changes: make(chan struct{}, 1),
+	}
+}
+
+// Load lists the known bulbs and queries their states in the background.
+func (d *Dashboard) Load() {
+	names := d.store.AllNames()
+
+	bulbs := make([]*dashboardBulb, 0, len(names))
+	for _, name := range names {
+		bulbs = append(bulbs, &dashboardBulb{name: name})
+	}
+
+	d.mu.Lock()
+	d.bulbs = bulbs
+	d.mu.Unlock()
+
+	for _, bulb := range bulbs {
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			d.query(bulb)
+		}()
+	}
+}
+
+// Wait waits for the queries and actions running in the background.
+func (d *Dashboard) Wait() {
+	d.wg.Wait()
+}
+
+func (d *Dashboard) query(bulb *dashboardBulb) {
+	state, err := d.control.State(bulb.name)
+
+	d.mu.Lock()
+	bulb.state, bulb.err, bulb.loaded = state.Main, err, true
+	d.mu.Unlock()
+
+	d.changed()
+}
+
+// Watch keeps bulb states up to date until the context is done and signals
+// every change on Changes.
+func (d *Dashboard) Watch(ctx context.Context) {
+	d.mu.Lock()
+	bulbs := d.bulbs
+	d.mu.Unlock()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for _, bulb := range bulbs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			watchBulb(ctx, d.store, d.dialer, bulb.name,
+				func(err error) { d.connected(bulb, err) },
+				func(props map[string]string) { d.notified(bulb, props) },
+			)
+		}()
+	}
+}
+
+func (d *Dashboard) Changes() <-chan struct{} {
+	return d.changes
+}
+
+func (d *Dashboard) changed() {
+	select {
+	case d.changes <- struct{}{}:
+	default:
+	}
+}
+
+func (d *Dashboard) connected(bulb *dashboardBulb, err error) {
+	d.mu.Lock()
+	reconnected := err == nil && bulb.err != nil
+	if err != nil {
+		bulb.err = err
+	}
+	d.mu.Unlock()
+
+	if reconnected {
+		d.query(bulb)
+
+		return
+	}
+
+	d.changed()
+}
+
+func (d *Dashboard) notified(bulb *dashboardBulb, props map[string]string) {
+	d.mu.Lock()
+	applyProps(&bulb.state, props)
+	bulb.err, bulb.loaded = nil, true
+	d.mu.Unlock()
+
+	d.changed()
+}
+
+func applyProps(state *LightState, props map[string]string) {
+	for name, value := range props {
+		number, _ := strconv.Atoi(value)
+
+		switch name {
+		case "power":
+			state.Power = yeelight.Power(value)
+		case "bright":
+			state.Bright = number
+		case "ct":
+			state.ColorTemperature = number
+		case "rgb":
+			state.RGB = number
+		case "hue":
+			state.HUE = number
+		case "sat":
+			state.Saturation = number
+		case "color_mode":
+			switch value {
+			case yeelight.ColorModeRGB:
+				state.ColorMode = ColorModeRGB
+			case yeelight.ColorModeTemperature:
+				state.ColorMode = ColorModeTemperature
+			case yeelight.ColorModeHSV:
+				state.ColorMode = ColorModeHSV
+			}
+		}
+	}
+}
+
+func (d *Dashboard) Select(delta int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if len(d.bulbs) == 0 {
+		return
+	}
+
+	d.selected = (d.selected + delta + len(d.bulbs)) % len(d.bulbs)
+}
+
+func (d *Dashboard) Toggle() {
+	d.act(func(name string) error {
+		return d.control.PowerToggle(name, LightsMain)
+	})
+}
+
+func (d *Dashboard) Dim(steps int) {
+	d.act(func(name string) error {
+		return d.control.AdjustBright(name, LightsMain, steps*dashboardBrightStep, false, dashboardDuration)
+	})
+}
+
+// Warm shifts the color temperature down by steps, or up for negative steps.
+func (d *Dashboard) Warm(steps int) {
+	d.act(func(name string) error {
+		return d.control.ShiftTemperature(name, LightsMain, -steps*dashboardTemperatureStep, false,
+			yeelight.EffectSmooth, dashboardDuration)
+	})
+}
+
+func (d *Dashboard) PickColor(index int) {
+	if index < 0 || index >= len(Palette) {
+		return
+	}
+
+	d.act(func(name string) error {
+		return d.control.SetRGB(name, LightsMain, Palette[index], false, yeelight.EffectSmooth, dashboardDuration)
+	})
+}
+
+// act queues the action on the selected bulb and keeps its error as the
+// message. Actions run in the background one by one in the order of keys.
+func (d *Dashboard) act(fn func(name string) error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if len(d.bulbs) == 0 {
+		return
+	}
+	name := d.bulbs[d.selected].name
+
+	d.actions = append(d.actions, func() {
+		err := fn(name)
+
+		d.mu.Lock()
+		d.message = ""
+		if err != nil {
+			d.message = err.Error()
+		}
+		d.mu.Unlock()
+
+		d.changed()
+	})
+
+	if !d.acting {
+		d.acting = true
+		d.wg.Add(1)
+		go d.runActions()
+	}
+}
+
+func (d *Dashboard) runActions() {
+	defer d.wg.Done()
+
+	for {
+		d.mu.Lock()
+		if len(d.actions) == 0 {
+			d.acting = false
+			d.mu.Unlock()
+
+			return
+		}
+		action := d.actions[0]
+		d.actions = d.actions[1:]
+		d.mu.Unlock()
+
+		action()
+	}
+}
+
+// Render prints the dashboard lines.
+func (d *Dashboard) Render() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if len(d.bulbs) == 0 {
+		d.printer.Println("No known bulbs, run ylc discover first")
+
+		return
+	}
+
+	width := 0
+	for _, bulb := range d.bulbs {
+		width = max(width, len(bulb.name))
+	}
+
+	for i, bulb := range d.bulbs {
+		cursor := " "
+		if i == d.selected {
+			cursor = ">"
+		}
+
+		d.printer.Printf("%s %-*s  %s\n", cursor, width, bulb.name, renderLight(bulb))
+	}
+
+	colors := make([]string, 0, len(Palette))
+	for i, rgb := range Palette {
+		colors = append(colors, fmt.Sprintf("%d %s", i+1, swatch(rgb)))
+	}
+	d.printer.Printf("\nColors: %s\n", strings.Join(colors, " "))
+
+	if d.message != "" {
+		d.printer.Printf("\n%s\n", d.message)
+	}
+}
+
+func renderLight(bulb *dashboardBulb) string {
+	if bulb.err != nil {
+		return "unreachable"
+	}
+
+	if !bulb.loaded {
+		return "loading"
+	}
+
+	state := bulb.state
+	if state.Power != yeelight.PowerOn {
+		return fmt.Sprintf("%-3s  %s", yeelight.PowerOff, strings.Repeat("·", brightBarWidth))
+	}
+
+	filled := (state.Bright*brightBarWidth + 50) / 100
+	bar := strings.Repeat("█", filled) + strings.Repeat("·", brightBarWidth-filled)
+
+	var rgb int
+	var label string
+	switch state.ColorMode {
+	case ColorModeRGB:
+		rgb, label = state.RGB, fmt.Sprintf("#%06x", state.RGB)
+	case ColorModeTemperature:
+		var err error
+		if rgb, err = color.Kelvin(state.ColorTemperature); err != nil {
+			return fmt.Sprintf("%-3s  %s %3d%%  %dK", state.Power, bar, state.Bright, state.ColorTemperature)
+		}
+		label = fmt.Sprintf("%dK", state.ColorTemperature)
+	case ColorModeHSV:
+		rgb, label = color.HSL(state.HUE, state.Saturation, 50), fmt.Sprintf("hsv %d/%d", state.HUE, state.Saturation)
+	default:
+		return fmt.Sprintf("%-3s  %s %3d%%", state.Power, bar, state.Bright)
+	}
+
+	return fmt.Sprintf("%-3s  %s %3d%%  %s %s", state.Power, bar, state.Bright, swatch(rgb), label)
+}
+
+// swatch is two spaces with the color as the terminal background.
+func swatch(rgb int) string {
+	return fmt.Sprintf("\x1b[48;2;%d;%d;%dm  \x1b[0m", rgb>>16&0xff, rgb>>8&0xff, rgb&0xff)
+}
@@ -0,0 +1,242 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/pugkong/ylc/yeelight"
+)
+
+type Conn interface {
+	yeelight.TCPConn
+	Close() error
+}
+
+const DefaultTimeout = 5 * time.Second
+
+type Dialer struct {
+	Tracer   *Tracer
+	Recorder *yeelight.Recorder
+	Limiter  *RateLimiter
+	Retry    RetryPolicy
+	Timeout  time.Duration
+	Pool     *ConnPool
+	DryRun   io.Writer
+
+	// Clamp brings values out of range to the nearest bound instead of
+	// failing before they are sent.
+	Clamp bool
+
+	// Check answers state queries of a dry run with empty props instead of
+	// failing, as checking a script needs no real bulb state.
+	Check bool
+}
+
+func (d *Dialer) Dial(bulb Bulb) (Conn, error) {
+	return d.dial(bulb, true)
+}
+
+// DialWatch connects to a bulb to read notifications. Reads have no timeout
+// and a failed connection is not restored.
+func (d *Dialer) DialWatch(bulb Bulb) (Conn, error) {
+	return d.dial(bulb, false)
+}
+
+func (d *Dialer) dial(bulb Bulb, commands bool) (Conn, error) {
+	addr, err := net.ResolveTCPAddr("tcp", bulb.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("resolve addr for %q bulb: %w", bulb.Name, err)
+	}
+
+	if d != nil && d.DryRun != nil {
+		return &dryRunConn{bulb: bulb.Name, w: d.DryRun, check: d.Check}, nil
+	}
+
+	var policy RetryPolicy
+	var timeout time.Duration
+	if d != nil {
+		policy = d.Retry
+		timeout = d.Timeout
+	}
+
+	connect := func() (*net.TCPConn, error) {
+		slog.Debug("connect to bulb", "bulb", bulb.Name, "addr", addr)
+
+		conn, err := (&net.Dialer{Timeout: timeout}).Dial("tcp", addr.String())
+		if err != nil {
+			return nil, fmt.Errorf("connect to %q bulb: %w", bulb.Name, err)
+		}
+
+		return conn.(*net.TCPConn), nil
+	}
+
+	var conn Conn
+	if commands && d != nil && d.Pool != nil {
+		if pooled := d.Pool.take(addr.String()); pooled != nil {
+			slog.Debug("reuse bulb connection", "bulb", bulb.Name, "addr", addr)
+
+			pooled.policy, pooled.timeout = policy, timeout
+			conn = &pooledConn{retryConn: pooled, pool: d.Pool, addr: addr.String()}
+		}
+	}
+
+	if conn == nil {
+		var tcpConn *net.TCPConn
+		err = policy.Do(func() (err error) {
+			tcpConn, err = connect()
+
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		conn = tcpConn
+		if commands {
+			retry := &retryConn{conn: tcpConn, dial: connect, policy: policy, timeout: timeout}
+			conn = retry
+			if d != nil && d.Pool != nil {
+				conn = &pooledConn{retryConn: retry, pool: d.Pool, addr: addr.String()}
+			}
+		}
+	}
+
+	if commands && d != nil && d.Limiter != nil {
+		conn = &limitConn{Conn: conn, limiter: d.Limiter, bulb: bulb}
+	}
+
+	if d != nil && d.Recorder != nil {
+		conn = &recordConn{Conn: conn, recorded: d.Recorder.TCPConn(conn, addr.String())}
+	}
+
+	if d != nil && d.Tracer != nil {
+		conn = d.Tracer.wrap(conn, bulb.Name)
+	}
+
+	return conn, nil
+}
+
+func (d *Dialer) dryRun() bool {
+	return d != nil && d.DryRun != nil
+}
+
+func (d *Dialer) ListenPacket(addr string) (net.PacketConn, error) {
+	if d != nil && d.DryRun != nil {
+		return dryRunPacketConn{}, nil
+	}
+
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("listen %q udp: %w", addr, err)
+	}
+
+	if d != nil && d.Recorder != nil {
+		return d.Recorder.PacketConn(conn), nil
+	}
+
+	return conn, nil
+}
+
+type recordConn struct {
+	Conn
+	recorded yeelight.TCPConn
+}
+
+func (c *recordConn) Write(b []byte) (int, error) {
+	return c.recorded.Write(b)
+}
+
+func (c *recordConn) Read(b []byte) (int, error) {
+	return c.recorded.Read(b)
+}
+
+// ConnPool keeps bulb connections open between commands run by one process.
+type ConnPool struct {
+	mu    sync.Mutex
+	conns map[string]*retryConn
+}
+
+func NewConnPool() *ConnPool {
+	return &ConnPool{conns: make(map[string]*retryConn)}
+}
+
+// take returns the kept connection to the address, unless the bulb closed it
+// meanwhile.
+func (p *ConnPool) take(addr string) *retryConn {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	conn := p.conns[addr]
+	delete(p.conns, addr)
+
+	if conn != nil && !conn.alive() {
+		slog.Debug("drop closed bulb connection", "addr", addr)
+		_ = conn.Close()
+
+		return nil
+	}
+
+	return conn
+}
+
+func (p *ConnPool) put(addr string, conn *retryConn) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.conns[addr]; ok {
+		return conn.Close()
+	}
+	p.conns[addr] = conn
+
+	return nil
+}
+
+// Close closes the kept connections.
+func (p *ConnPool) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var errs []error
+	for addr, conn := range p.conns {
+		errs = append(errs, conn.Close())
+		delete(p.conns, addr)
+	}
+
+	return errors.Join(errs...)
+}
+
+// pooledConn returns the connection to the pool on close, unless it failed
+// and may hold a half read response.
+type pooledConn struct {
+	*retryConn
+	pool   *ConnPool
+	addr   string
+	failed bool
+}
+
+func (c *pooledConn) Write(b []byte) (int, error) {
+	n, err := c.retryConn.Write(b)
+	c.failed = c.failed || err != nil
+
+	return n, err
+}
+
+func (c *pooledConn) Read(b []byte) (int, error) {
+	n, err := c.retryConn.Read(b)
+	c.failed = c.failed || err != nil && !errors.Is(err, yeelight.ErrReconnected)
+
+	return n, err
+}
+
+func (c *pooledConn) Close() error {
+	if c.failed {
+		return c.retryConn.Close()
+	}
+
+	return c.pool.put(c.addr, c.retryConn)
+}
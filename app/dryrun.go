@@ -0,0 +1,125 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"time"
+)
+
+// ErrDryRunState fails commands which depend on the bulb state, which a dry
+// run does not know, rather than printing commands made up from empty props.
+var ErrDryRunState = errors.New("dry run does not know bulb state")
+
+// dryRunConn takes the place of a bulb connection in a dry run. It writes the
+// sent commands to w and answers them without a bulb: changes succeed and
+// queries fail with ErrDryRunState, or have empty props in a check.
+type dryRunConn struct {
+	bulb     string
+	w        io.Writer
+	check    bool
+	sent     bytes.Buffer
+	response bytes.Buffer
+}
+
+type dryRunCommand struct {
+	ID     int    `json:"id"`
+	Method string `json:"method"`
+	Params []any  `json:"params"`
+}
+
+func (c *dryRunConn) Write(b []byte) (int, error) {
+	c.sent.Write(b)
+
+	for {
+		line, err := c.sent.ReadBytes('\n')
+		if err != nil {
+			c.sent.Write(line)
+
+			return len(b), nil
+		}
+
+		if err := c.answer(bytes.TrimRight(line, "\r\n")); err != nil {
+			return 0, err
+		}
+	}
+}
+
+func (c *dryRunConn) answer(line []byte) error {
+	if _, err := fmt.Fprintf(c.w, "%s %s\n", c.bulb, line); err != nil {
+		return fmt.Errorf("write dry run: %w", err)
+	}
+
+	var command dryRunCommand
+	if err := json.Unmarshal(line, &command); err != nil {
+		return fmt.Errorf("parse dry run command: %w", err)
+	}
+
+	var result any = []string{"ok"}
+	switch command.Method {
+	case "get_prop":
+		result = make([]string, len(command.Params))
+	case "cron_get":
+		result = []any{}
+	}
+
+	if !c.check && (command.Method == "get_prop" || command.Method == "cron_get") {
+		return fmt.Errorf("%w: %s needs an answer from the bulb", ErrDryRunState, command.Method)
+	}
+
+	data, err := json.Marshal(map[string]any{"id": command.ID, "result": result})
+	if err != nil {
+		return fmt.Errorf("prepare dry run response: %w", err)
+	}
+
+	c.response.Write(append(data, '\r', '\n'))
+
+	return nil
+}
+
+func (c *dryRunConn) Read(b []byte) (int, error) {
+	if c.response.Len() == 0 {
+		return 0, io.EOF
+	}
+
+	return c.response.Read(b)
+}
+
+func (c *dryRunConn) Close() error {
+	return nil
+}
+
+// dryRunPacketConn sends discover messages nowhere and receives no answers.
+type dryRunPacketConn struct{}
+
+func (dryRunPacketConn) ReadFrom([]byte) (int, net.Addr, error) {
+	return 0, nil, os.ErrDeadlineExceeded
+}
+
+func (dryRunPacketConn) WriteTo(b []byte, _ net.Addr) (int, error) {
+	return len(b), nil
+}
+
+func (dryRunPacketConn) Close() error {
+	return nil
+}
+
+func (dryRunPacketConn) LocalAddr() net.Addr {
+	return &net.UDPAddr{}
+}
+
+func (dryRunPacketConn) SetDeadline(time.Time) error {
+	return nil
+}
+
+func (dryRunPacketConn) SetReadDeadline(time.Time) error {
+	return nil
+}
+
+func (dryRunPacketConn) SetWriteDeadline(time.Time) error {
+	return nil
+}
@@ -0,0 +1,285 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pugkong/ylc/yeelight"
+)
+
+// latencyBuckets are the upper bounds in seconds of the state query latency
+// histogram.
+var latencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5}
+
+type histogram struct {
+	counts []int
+	sum    float64
+	count  int
+}
+
+func (h *histogram) observe(seconds float64) {
+	for i, bound := range latencyBuckets {
+		if seconds <= bound {
+			h.counts[i]++
+		}
+	}
+
+	h.sum += seconds
+	h.count++
+}
+
+type exportedBulb struct {
+	name      string
+	state     LightState
+	known     bool
+	reachable bool
+	lastSeen  time.Time
+	latency   histogram
+
+	queryErrors      int
+	watchDisconnects int
+}
+
+// Exporter keeps the main light state of every known bulb up to date through
+// notifications and queries it every interval, and writes it all as
+// Prometheus metrics.
+type Exporter struct {
+	store    *BulbFileStore
+	dialer   *Dialer
+	control  *Control
+	interval time.Duration
+
+	mu    sync.Mutex
+	bulbs []*exportedBulb
+}
+
+func NewExporter(store *BulbFileStore, dialer *Dialer, interval time.Duration, printer Printer) *Exporter {
+	names := store.AllNames()
+
+	bulbs := make([]*exportedBulb, 0, len(names))
+	for _, name := range names {
+		bulbs = append(bulbs, &exportedBulb{name: name, latency: histogram{counts: make([]int, len(latencyBuckets))}})
+	}
+
+	return &Exporter{
+		store:    store,
+		dialer:   dialer,
+		control:  NewControl(store, dialer, printer),
+		interval: interval,
+		bulbs:    bulbs,
+	}
+}
+
+// Run gathers bulb states until the context is done.
+func (e *Exporter) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for _, bulb := range e.bulbs {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			watchBulb(ctx, e.store, e.dialer, bulb.name,
+				func(err error) { e.connected(bulb, err) },
+				func(props map[string]string) { e.notified(bulb, props) },
+			)
+		}()
+		go func() {
+			defer wg.Done()
+			e.poll(ctx, bulb)
+		}()
+	}
+}
+
+func (e *Exporter) poll(ctx context.Context, bulb *exportedBulb) {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		e.query(bulb)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (e *Exporter) query(bulb *exportedBulb) {
+	start := time.Now()
+	state, err := e.control.State(bulb.name)
+	end := time.Now()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err != nil {
+		bulb.reachable = false
+		bulb.queryErrors++
+
+		return
+	}
+
+	bulb.latency.observe(end.Sub(start).Seconds())
+	bulb.state, bulb.known = state.Main, true
+	bulb.reachable, bulb.lastSeen = true, end
+}
+
+func (e *Exporter) connected(bulb *exportedBulb, err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err != nil {
+		bulb.watchDisconnects++
+	}
+}
+
+func (e *Exporter) notified(bulb *exportedBulb, props map[string]string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	applyProps(&bulb.state, props)
+	// Notifications carry only the changed props, so gauges of props not
+	// heard of yet stay missing.
+	bulb.known, bulb.lastSeen = true, time.Now()
+}
+
+type sample struct {
+	suffix string
+	labels string
+	value  float64
+}
+
+// WriteMetrics writes the metrics in the Prometheus text format.
+func (e *Exporter) WriteMetrics(w io.Writer) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var b strings.Builder
+
+	e.family(&b, "ylc_bulb_reachable", "gauge", "Whether the bulb answered the last state query.",
+		func(bulb *exportedBulb) []sample {
+			return []sample{{value: boolValue(bulb.reachable)}}
+		})
+
+	e.family(&b, "ylc_bulb_last_seen_timestamp_seconds", "gauge", "When the bulb was last heard from.",
+		func(bulb *exportedBulb) []sample {
+			if bulb.lastSeen.IsZero() {
+				return nil
+			}
+
+			return []sample{{value: float64(bulb.lastSeen.UnixMilli()) / 1000}}
+		})
+
+	e.family(&b, "ylc_bulb_power", "gauge", "Whether the main light is on.",
+		func(bulb *exportedBulb) []sample {
+			if !bulb.known || bulb.state.Power == "" {
+				return nil
+			}
+
+			return []sample{{value: boolValue(bulb.state.Power == yeelight.PowerOn)}}
+		})
+
+	e.family(&b, "ylc_bulb_bright_percent", "gauge", "Brightness of the main light.",
+		func(bulb *exportedBulb) []sample {
+			if !bulb.known || bulb.state.Bright == 0 {
+				return nil
+			}
+
+			return []sample{{value: float64(bulb.state.Bright)}}
+		})
+
+	e.family(&b, "ylc_bulb_color_temperature_kelvin", "gauge", "Color temperature of the main light in temperature mode.",
+		func(bulb *exportedBulb) []sample {
+			if !bulb.known || bulb.state.ColorMode != ColorModeTemperature {
+				return nil
+			}
+
+			return []sample{{value: float64(bulb.state.ColorTemperature)}}
+		})
+
+	e.family(&b, "ylc_bulb_rgb", "gauge", "RGB components of the main light in RGB mode.",
+		func(bulb *exportedBulb) []sample {
+			if !bulb.known || bulb.state.ColorMode != ColorModeRGB {
+				return nil
+			}
+
+			rgb := bulb.state.RGB
+
+			return []sample{
+				{labels: `,component="red"`, value: float64(rgb >> 16 & 0xff)},
+				{labels: `,component="green"`, value: float64(rgb >> 8 & 0xff)},
+				{labels: `,component="blue"`, value: float64(rgb & 0xff)},
+			}
+		})
+
+	e.family(&b, "ylc_bulb_query_duration_seconds", "histogram", "Latency of state queries answered by the bulb, retries included.",
+		func(bulb *exportedBulb) []sample {
+			h := bulb.latency
+			samples := make([]sample, 0, len(latencyBuckets)+3)
+			for i, bound := range latencyBuckets {
+				samples = append(samples, sample{
+					suffix: "_bucket",
+					labels: fmt.Sprintf(`,le="%s"`, formatValue(bound)),
+					value:  float64(h.counts[i]),
+				})
+			}
+
+			return append(samples,
+				sample{suffix: "_bucket", labels: `,le="+Inf"`, value: float64(h.count)},
+				sample{suffix: "_sum", value: h.sum},
+				sample{suffix: "_count", value: float64(h.count)},
+			)
+		})
+
+	e.family(&b, "ylc_bulb_query_errors_total", "counter", "Failed state queries.",
+		func(bulb *exportedBulb) []sample {
+			return []sample{{value: float64(bulb.queryErrors)}}
+		})
+
+	e.family(&b, "ylc_bulb_watch_disconnects_total", "counter", "Failed or lost notification connections.",
+		func(bulb *exportedBulb) []sample {
+			return []sample{{value: float64(bulb.watchDisconnects)}}
+		})
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("write metrics: %w", err)
+	}
+
+	return nil
+}
+
+// family writes a metric with the samples of every bulb.
+func (e *Exporter) family(b *strings.Builder, name, kind, help string, samples func(*exportedBulb) []sample) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+
+	for _, bulb := range e.bulbs {
+		for _, s := range samples(bulb) {
+			fmt.Fprintf(b, "%s%s{bulb=\"%s\"%s} %s\n", name, s.suffix, labelValue(bulb.name), s.labels, formatValue(s.value))
+		}
+	}
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func labelValue(value string) string {
+	return labelEscaper.Replace(value)
+}
+
+func formatValue(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+func boolValue(value bool) float64 {
+	if value {
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,62 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+func readJSONFile(filePath string, v any) error {
+	bytes, err := os.ReadFile(filePath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+
+		return fmt.Errorf("read data from %q: %w", filePath, err)
+	}
+
+	if err := json.Unmarshal(bytes, v); err != nil {
+		return fmt.Errorf("decode data from %q: %w", filePath, err)
+	}
+
+	return nil
+}
+
+func writeJSONFile(filePath string, v any) error {
+	bytes, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("encode data: %w", err)
+	}
+
+	if err := os.WriteFile(filePath, bytes, 0o600); err != nil {
+		return fmt.Errorf("save data to %q: %w", filePath, err)
+	}
+
+	return nil
+}
+
+// lockJSONFile takes an exclusive lock on a lock file next to the data file,
+// so ylc runs read, change and write the data one at a time. The returned
+// function releases the lock.
+func lockJSONFile(filePath string) (func() error, error) {
+	lockPath := filePath + ".lock"
+
+	file, err := os.OpenFile(lockPath, os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("open lock %q: %w", lockPath, err)
+	}
+
+	if err := lockFile(file); err != nil {
+		return nil, errors.Join(fmt.Errorf("lock %q: %w", lockPath, err), file.Close())
+	}
+
+	return func() error {
+		if err := errors.Join(unlockFile(file), file.Close()); err != nil {
+			return fmt.Errorf("unlock %q: %w", lockPath, err)
+		}
+
+		return nil
+	}, nil
+}
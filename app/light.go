@@ -0,0 +1,187 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/pugkong/ylc/yeelight"
+)
+
+type Lights string
+
+const (
+	LightsMain       Lights = "main"
+	LightsBackground Lights = "bg"
+	LightsBoth       Lights = "both"
+)
+
+var ErrUnknownLights = errors.New("unknown light")
+
+func ParseLights(value string) (Lights, error) {
+	switch lights := Lights(value); lights {
+	case LightsMain, LightsBackground, LightsBoth:
+		return lights, nil
+	}
+
+	return "", fmt.Errorf("%w: %q", ErrUnknownLights, value)
+}
+
+type light struct {
+	label string
+	state func(BulbState) *LightState
+
+	power                  func(yeelight.Power, yeelight.Effect, int, yeelight.PowerMode) error
+	toggle                 func() error
+	bright                 func(int, yeelight.Effect, int) error
+	adjustBright           func(int, int) error
+	colorTemperature       func(int, yeelight.Effect, int) error
+	adjustColorTemperature func(int, int) error
+	rgb                    func(int, yeelight.Effect, int) error
+	hsv                    func(int, int, yeelight.Effect, int) error
+	saveDefault            func() error
+	startFlow              func(int, yeelight.FlowAction, []yeelight.FlowTransition) error
+	flowScene              func(int, yeelight.FlowAction, []yeelight.FlowTransition) error
+}
+
+func mainLight(controller *yeelight.Controller) light {
+	return light{
+		label: "",
+		state: func(state BulbState) *LightState { return &state.Main },
+
+		power:                  controller.Power,
+		toggle:                 controller.Toggle,
+		bright:                 controller.Bright,
+		adjustBright:           controller.AdjustBright,
+		colorTemperature:       controller.ColorTemperature,
+		adjustColorTemperature: controller.AdjustColorTemperature,
+		rgb:                    controller.RGB,
+		hsv:                    controller.HSV,
+		saveDefault:            controller.SaveDefault,
+		startFlow:              controller.StartFlow,
+		flowScene:              controller.FlowScene,
+	}
+}
+
+func backgroundLight(controller *yeelight.Controller) light {
+	return light{
+		label: "background ",
+		state: func(state BulbState) *LightState { return state.Background },
+
+		power:                  controller.BackgroundPower,
+		toggle:                 controller.BackgroundToggle,
+		bright:                 controller.BackgroundBright,
+		adjustBright:           controller.BackgroundAdjustBright,
+		colorTemperature:       controller.BackgroundColorTemperature,
+		adjustColorTemperature: controller.BackgroundAdjustColorTemperature,
+		rgb:                    controller.BackgroundRGB,
+		hsv:                    controller.BackgroundHSV,
+		saveDefault:            controller.BackgroundSaveDefault,
+		startFlow:              controller.BackgroundStartFlow,
+		flowScene:              controller.BackgroundFlowScene,
+	}
+}
+
+func selectLights(controller *yeelight.Controller, lights Lights) []light {
+	switch lights {
+	case LightsBackground:
+		return []light{backgroundLight(controller)}
+	case LightsBoth:
+		return []light{mainLight(controller), backgroundLight(controller)}
+	default:
+		return []light{mainLight(controller)}
+	}
+}
+
+func (l light) apply(state LightState, mode yeelight.PowerMode, effect yeelight.Effect, duration int) error {
+	if state.Power != yeelight.PowerOn {
+		if err := l.power(yeelight.PowerOff, effect, duration, yeelight.PowerModeNormal); err != nil {
+			return fmt.Errorf("power off: %w", err)
+		}
+
+		return nil
+	}
+
+	if err := l.power(yeelight.PowerOn, effect, duration, mode); err != nil {
+		return fmt.Errorf("power on: %w", err)
+	}
+
+	switch state.ColorMode {
+	case ColorModeRGB:
+		if err := l.rgb(state.RGB, effect, duration); err != nil {
+			return fmt.Errorf("set rgb color: %w", err)
+		}
+	case ColorModeTemperature:
+		if err := l.colorTemperature(state.ColorTemperature, effect, duration); err != nil {
+			return fmt.Errorf("set temperature: %w", err)
+		}
+	case ColorModeHSV:
+		if err := l.hsv(state.HUE, state.Saturation, effect, duration); err != nil {
+			return fmt.Errorf("set hsv color: %w", err)
+		}
+	case "":
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownColorMode, state.ColorMode)
+	}
+
+	if state.Bright != 0 {
+		if err := l.bright(state.Bright, effect, duration); err != nil {
+			return fmt.Errorf("set bright: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func applyState(controller *yeelight.Controller, state BulbState, effect yeelight.Effect, duration int) error {
+	main := mainLight(controller)
+
+	switch {
+	case state.Mode == ModeMoonlight && state.Main.Power == yeelight.PowerOn:
+		if err := applyNightLight(main, state.NightLightBright, effect, duration); err != nil {
+			return err
+		}
+	case state.Mode == ModeDaylight:
+		// Leave the night light, which the normal power mode keeps.
+		if err := main.apply(state.Main, daylightPowerMode(state.Main), effect, duration); err != nil {
+			return err
+		}
+	default:
+		if err := main.apply(state.Main, yeelight.PowerModeNormal, effect, duration); err != nil {
+			return err
+		}
+	}
+
+	if state.Background != nil {
+		background := backgroundLight(controller)
+		if err := background.apply(*state.Background, yeelight.PowerModeNormal, effect, duration); err != nil {
+			return fmt.Errorf("background: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func applyNightLight(l light, bright int, effect yeelight.Effect, duration int) error {
+	if err := l.power(yeelight.PowerOn, effect, duration, yeelight.PowerModeNightLight); err != nil {
+		return fmt.Errorf("turn on night light: %w", err)
+	}
+
+	if bright != 0 {
+		if err := l.bright(bright, effect, duration); err != nil {
+			return fmt.Errorf("set night light bright: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func daylightPowerMode(state LightState) yeelight.PowerMode {
+	switch state.ColorMode {
+	case ColorModeRGB:
+		return yeelight.PowerModeRGB
+	case ColorModeHSV:
+		return yeelight.PowerModeHSV
+	default:
+		return yeelight.PowerModeTemperature
+	}
+}
@@ -0,0 +1,81 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/pugkong/ylc/sun"
+)
+
+type Location struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Timezone  string  `json:"timezone"`
+}
+
+var ErrNoLocation = errors.New("location is not configured")
+
+func (l *Location) timezone() (*time.Location, error) {
+	if l.Timezone == "" {
+		return time.Local, nil
+	}
+
+	tz, err := time.LoadLocation(l.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("load %q timezone: %w", l.Timezone, err)
+	}
+
+	return tz, nil
+}
+
+func (l *Location) sunTimes(date time.Time) (sunrise, sunset time.Time, err error) {
+	return sun.Times(date, l.Latitude, l.Longitude)
+}
+
+type Sun struct {
+	location *Location
+	printer  Printer
+}
+
+func NewSun(location *Location, printer Printer) *Sun {
+	return &Sun{location: location, printer: printer}
+}
+
+// Show prints sun times on the calendar day of date at the location, or today
+// there for the zero date.
+func (s *Sun) Show(date time.Time) error {
+	if s.location == nil {
+		return ErrNoLocation
+	}
+
+	tz, err := s.location.timezone()
+	if err != nil {
+		return err
+	}
+
+	if date.IsZero() {
+		date = time.Now().In(tz)
+	}
+	date = time.Date(date.Year(), date.Month(), date.Day(), 12, 0, 0, 0, tz)
+
+	sunrise, sunset, err := s.location.sunTimes(date)
+	switch {
+	case errors.Is(err, sun.ErrPolarDay):
+		s.printer.Println("The sun does not set today")
+
+		return nil
+	case errors.Is(err, sun.ErrPolarNight):
+		s.printer.Println("The sun does not rise today")
+
+		return nil
+	case err != nil:
+		return err
+	}
+
+	s.printer.Printf("Sunrise: %s\n", sunrise.Format("15:04"))
+	s.printer.Printf("Sunset: %s\n", sunset.Format("15:04"))
+	s.printer.Printf("Day length: %s\n", sunset.Sub(sunrise).Truncate(time.Minute))
+
+	return nil
+}
@@ -3,6 +3,7 @@ package app
 import (
 	"errors"
 	"fmt"
+	"log/slog"
 	"net"
 	"slices"
 	"time"
@@ -13,13 +14,15 @@ import (
 
 type Manager struct {
 	store   *BulbFileStore
+	dialer  *Dialer
 	names   *pokemon.Names
 	printer Printer
 }
 
-func NewManager(store *BulbFileStore, names *pokemon.Names, printer Printer) *Manager {
+func NewManager(store *BulbFileStore, dialer *Dialer, names *pokemon.Names, printer Printer) *Manager {
 	return &Manager{
 		store:   store,
+		dialer:  dialer,
 		names:   names,
 		printer: printer,
 	}
@@ -31,6 +34,25 @@ func (m *Manager) List() error {
 	return nil
 }
 
+func (m *Manager) ListWithModes() error {
+	const format = " %12s %18s %18s %10s\n"
+
+	control := NewControl(m.store, m.dialer, m.printer)
+
+	m.printer.Printf(format, "Name", "Address", "ID", "Mode")
+	for _, bulb := range m.store.All() {
+		mode, err := control.Mode(bulb.Name)
+		if err != nil {
+			slog.Info("query bulb mode", "bulb", bulb.Name, "err", err)
+			mode = "unreachable"
+		}
+
+		m.printer.Printf(format, bulb.Name, bulb.Addr, bulb.ID, mode)
+	}
+
+	return nil
+}
+
 func (m *Manager) printList(bulbs []Bulb) {
 	const format = " %12s %18s %18s\n"
 
@@ -40,10 +62,15 @@ func (m *Manager) printList(bulbs []Bulb) {
 	}
 }
 
-func (m *Manager) Discover(listen string, duration time.Duration) error {
-	conn, err := net.ListenPacket("udp", listen)
+func (m *Manager) Discover(listen, addr string, duration time.Duration) error {
+	udpAddr, err := net.ResolveUDPAddr("udp", addr)
 	if err != nil {
-		return fmt.Errorf("listen %q udp: %w", listen, err)
+		return fmt.Errorf("resolve %q addr: %w", addr, err)
+	}
+
+	conn, err := m.dialer.ListenPacket(listen)
+	if err != nil {
+		return err
 	}
 	defer conn.Close()
 
@@ -51,7 +78,7 @@ func (m *Manager) Discover(listen string, duration time.Duration) error {
 		return fmt.Errorf("set timeout: %w", err)
 	}
 
-	rawBulbs, err := m.discoverBulbs(yeelight.NewDiscoverer(conn))
+	rawBulbs, err := m.discoverBulbs(yeelight.NewDiscoverer(conn, udpAddr))
 	if err != nil {
 		return err
 	}
@@ -84,6 +111,8 @@ func (m *Manager) discoverBulbs(discoverer *yeelight.Discoverer) ([]yeelight.Bul
 		return nil, fmt.Errorf("discover: %w", err)
 	}
 
+	slog.Debug("sent discover message")
+
 	var bulbs []yeelight.Bulb
 	for {
 		bulb, err := discoverer.ReadBulb()
@@ -117,6 +146,7 @@ func (m *Manager) saveBulb(rawBulb yeelight.Bulb) (Bulb, error) {
 	case err != nil:
 		return Bulb{}, err
 	default:
+		slog.Debug("update bulb addr", "bulb", bulb.Name, "addr", rawBulb.Addr)
 		bulb.Addr = rawBulb.Addr
 	}
 
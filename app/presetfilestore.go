@@ -0,0 +1,77 @@
+package app
+
+import (
+	"cmp"
+	"errors"
+	"path"
+	"slices"
+)
+
+type Preset struct {
+	Name  string    `json:"name"`
+	State BulbState `json:"state"`
+}
+
+type PresetFileStore struct {
+	presets map[string]Preset
+	dir     string
+}
+
+func NewPresetFileStore(dir string) *PresetFileStore {
+	return &PresetFileStore{
+		presets: make(map[string]Preset),
+		dir:     dir,
+	}
+}
+
+func (p *PresetFileStore) Init() error {
+	return readJSONFile(p.presetsPath(), &p.presets)
+}
+
+func (p *PresetFileStore) All() []Preset {
+	presets := make([]Preset, 0, len(p.presets))
+	for _, preset := range p.presets {
+		presets = append(presets, preset)
+	}
+
+	slices.SortFunc(presets, func(x, y Preset) int { return cmp.Compare(x.Name, y.Name) })
+
+	return presets
+}
+
+func (p *PresetFileStore) AllNames() []string {
+	presets := p.All()
+	names := make([]string, 0, len(presets))
+	for _, preset := range presets {
+		names = append(names, preset.Name)
+	}
+
+	return names
+}
+
+var ErrPresetNotFound = errors.New("not found")
+
+func (p *PresetFileStore) FindByName(name string) (Preset, error) {
+	preset, ok := p.presets[name]
+	if ok {
+		return preset, nil
+	}
+
+	return Preset{}, ErrPresetNotFound
+}
+
+func (p *PresetFileStore) Save(preset Preset) {
+	p.presets[preset.Name] = preset
+}
+
+func (p *PresetFileStore) Delete(preset Preset) {
+	delete(p.presets, preset.Name)
+}
+
+func (p *PresetFileStore) Flush() error {
+	return writeJSONFile(p.presetsPath(), p.presets)
+}
+
+func (p *PresetFileStore) presetsPath() string {
+	return path.Join(p.dir, "presets.json")
+}
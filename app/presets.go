@@ -0,0 +1,80 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/pugkong/ylc/yeelight"
+)
+
+type Presets struct {
+	store   *PresetFileStore
+	control *Control
+	printer Printer
+}
+
+func NewPresets(bulbs *BulbFileStore, store *PresetFileStore, dialer *Dialer, printer Printer) *Presets {
+	return &Presets{
+		store:   store,
+		control: NewControl(bulbs, dialer, printer),
+		printer: printer,
+	}
+}
+
+func (p *Presets) Save(preset Preset) error {
+	p.store.Save(preset)
+
+	return p.store.Flush()
+}
+
+func (p *Presets) SaveFromBulb(name, bulbName string) error {
+	state, err := p.control.State(bulbName)
+	if err != nil {
+		return err
+	}
+
+	return p.Save(Preset{Name: name, State: state})
+}
+
+func (p *Presets) List() error {
+	const format = " %12s %-32s %s\n"
+
+	p.printer.Printf(format, "Name", "Main", "Background")
+	for _, preset := range p.store.All() {
+		background := ""
+		if preset.State.Background != nil {
+			background = preset.State.Background.String()
+		}
+
+		p.printer.Printf(format, preset.Name, mainString(preset.State), background)
+	}
+
+	return nil
+}
+
+func mainString(state BulbState) string {
+	if state.Mode == ModeMoonlight && state.Main.Power == yeelight.PowerOn {
+		return fmt.Sprintf("%s, %s %d%%", state.Main.Power, ModeMoonlight, state.NightLightBright)
+	}
+
+	return state.Main.String()
+}
+
+func (p *Presets) Delete(name string) error {
+	preset, err := p.store.FindByName(name)
+	if err != nil {
+		return fmt.Errorf("find %q preset: %w", name, err)
+	}
+
+	p.store.Delete(preset)
+
+	return p.store.Flush()
+}
+
+func (p *Presets) Apply(name, bulbName string, effect yeelight.Effect, duration int) error {
+	preset, err := p.store.FindByName(name)
+	if err != nil {
+		return fmt.Errorf("find %q preset: %w", name, err)
+	}
+
+	return p.control.ApplyState(bulbName, preset.State, effect, duration)
+}
@@ -0,0 +1,161 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/pugkong/ylc/yeelight"
+)
+
+const (
+	QuotaCommands = 60
+	QuotaPeriod   = time.Minute
+)
+
+type QuotaError struct {
+	Bulb       string
+	RetryAfter time.Duration
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("%s: %q bulb allows %d commands per %s, retry in %s or use --wait",
+		yeelight.ErrQuotaExceeded, e.Bulb, QuotaCommands, QuotaPeriod, e.RetryAfter.Round(time.Millisecond))
+}
+
+func (e *QuotaError) Unwrap() error {
+	return yeelight.ErrQuotaExceeded
+}
+
+type quotaBucket struct {
+	Tokens  float64   `json:"tokens"`
+	Updated time.Time `json:"updated"`
+}
+
+// RateLimiter keeps a token bucket per bulb. The buckets are saved to the
+// cache dir on every command under a file lock, so the quota holds across
+// ylc runs from the same script and across concurrent runs.
+type RateLimiter struct {
+	mu       sync.Mutex
+	buckets  map[string]*quotaBucket
+	dir      string
+	commands int
+	period   time.Duration
+	wait     bool
+
+	now   func() time.Time
+	sleep func(time.Duration)
+}
+
+func NewRateLimiter(dir string, commands int, period time.Duration, wait bool) *RateLimiter {
+	return &RateLimiter{
+		buckets:  make(map[string]*quotaBucket),
+		dir:      dir,
+		commands: commands,
+		period:   period,
+		wait:     wait,
+		now:      time.Now,
+		sleep:    time.Sleep,
+	}
+}
+
+func (l *RateLimiter) quotaPath() string {
+	return path.Join(l.dir, "quota.json")
+}
+
+// Take spends a command of the bulb quota. It either waits for the bucket to
+// refill or fails with QuotaError. Waiting callers reserve their token before
+// sleeping, so concurrent commands to the same bulb queue up in order.
+func (l *RateLimiter) Take(bulb Bulb) error {
+	delay, err := l.reserve(bulb)
+	if err != nil {
+		return err
+	}
+
+	if delay > 0 {
+		l.sleep(delay)
+	}
+
+	return nil
+}
+
+func (l *RateLimiter) reserve(bulb Bulb) (delay time.Duration, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.dir == "" {
+		return l.spend(bulb)
+	}
+
+	unlock, err := lockJSONFile(l.quotaPath())
+	if err != nil {
+		return 0, fmt.Errorf("lock quota store: %w", err)
+	}
+	defer func() {
+		if unlockErr := unlock(); unlockErr != nil {
+			err = errors.Join(err, fmt.Errorf("unlock quota store: %w", unlockErr))
+		}
+	}()
+
+	buckets := make(map[string]*quotaBucket)
+	if err := readJSONFile(l.quotaPath(), &buckets); err != nil {
+		return 0, fmt.Errorf("read quota store: %w", err)
+	}
+	l.buckets = buckets
+
+	delay, err = l.spend(bulb)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := writeJSONFile(l.quotaPath(), l.buckets); err != nil {
+		return 0, fmt.Errorf("write quota store: %w", err)
+	}
+
+	return delay, nil
+}
+
+// spend refills the bulb bucket and takes a token from it.
+func (l *RateLimiter) spend(bulb Bulb) (time.Duration, error) {
+	now := l.now()
+	limit := float64(l.commands)
+	perToken := l.period / time.Duration(l.commands)
+
+	bucket, ok := l.buckets[bulb.ID]
+	if !ok {
+		bucket = &quotaBucket{Tokens: limit, Updated: now}
+		l.buckets[bulb.ID] = bucket
+	}
+
+	if elapsed := now.Sub(bucket.Updated); elapsed > 0 {
+		bucket.Tokens = min(bucket.Tokens+float64(elapsed)/float64(perToken), limit)
+		bucket.Updated = now
+	}
+
+	if bucket.Tokens < 1 && !l.wait {
+		return 0, &QuotaError{Bulb: bulb.Name, RetryAfter: time.Duration((1 - bucket.Tokens) * float64(perToken))}
+	}
+
+	bucket.Tokens--
+	if bucket.Tokens >= 0 {
+		return 0, nil
+	}
+
+	return time.Duration(-bucket.Tokens * float64(perToken)), nil
+}
+
+type limitConn struct {
+	Conn
+	limiter *RateLimiter
+	bulb    Bulb
+}
+
+func (c *limitConn) Write(b []byte) (int, error) {
+	if err := c.limiter.Take(c.bulb); err != nil {
+		return 0, err
+	}
+
+	return c.Conn.Write(b)
+}
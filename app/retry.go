@@ -0,0 +1,271 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"slices"
+	"time"
+
+	"github.com/pugkong/ylc/yeelight"
+)
+
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("parse duration: %w", err)
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return fmt.Errorf("parse duration: %w", err)
+	}
+
+	*d = Duration(duration)
+
+	return nil
+}
+
+type RetryPolicy struct {
+	Attempts   int      `json:"attempts"`
+	Backoff    Duration `json:"backoff"`
+	MaxBackoff Duration `json:"max_backoff"`
+	Jitter     float64  `json:"jitter"`
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	Attempts:   3,
+	Backoff:    Duration(250 * time.Millisecond),
+	MaxBackoff: Duration(2 * time.Second),
+	Jitter:     0.2,
+}
+
+// Do runs fn until it succeeds, fails with an error which is not worth
+// retrying or runs out of attempts.
+func (p RetryPolicy) Do(fn func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= p.Attempts || !retryable(err) {
+			return err
+		}
+
+		delay := p.delay(attempt)
+		slog.Debug("retry", "attempt", attempt, "delay", delay, "err", err)
+		time.Sleep(delay)
+	}
+}
+
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	delay := time.Duration(p.Backoff) << (attempt - 1)
+	if p.MaxBackoff > 0 {
+		delay = min(delay, time.Duration(p.MaxBackoff))
+	}
+
+	jitter := 1 + p.Jitter*(2*rand.Float64()-1)
+
+	return time.Duration(float64(delay) * jitter)
+}
+
+// retryable tells network failures, which may pass, from errors reported by
+// the bulb or the quota limiter, which would only repeat.
+func retryable(err error) bool {
+	var bulbErr *yeelight.BulbError
+	var quotaErr *QuotaError
+	if errors.As(err, &bulbErr) || errors.As(err, &quotaErr) {
+		return false
+	}
+
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	var netErr net.Error
+
+	return errors.As(err, &netErr)
+}
+
+// unsafeMethods change the bulb relative to its current state, so sending
+// them again after a lost response could apply them twice.
+var unsafeMethods = []string{
+	"toggle",
+	"dev_toggle",
+	"bg_toggle",
+	"set_adjust",
+	"bg_set_adjust",
+	"adjust_bright",
+	"adjust_ct",
+	"adjust_color",
+	"bg_adjust_bright",
+	"bg_adjust_ct",
+	"bg_adjust_color",
+}
+
+func resendable(command []byte) bool {
+	var message struct {
+		Method string `json:"method"`
+	}
+
+	if err := json.Unmarshal(command, &message); err != nil {
+		return false
+	}
+
+	return !slices.Contains(unsafeMethods, message.Method)
+}
+
+// retryConn reconnects to a bulb when the connection fails and sends the last
+// command again if it is safe to repeat. After reconnecting, Read returns
+// yeelight.ErrReconnected once, so the reader drops the data it buffered
+// from the old connection, which may end in a partial line.
+type retryConn struct {
+	conn        *net.TCPConn
+	dial        func() (*net.TCPConn, error)
+	policy      RetryPolicy
+	timeout     time.Duration
+	last        []byte
+	resends     int
+	reconnected bool
+	pending     []byte
+}
+
+func (c *retryConn) Write(b []byte) (int, error) {
+	c.last = append(c.last[:0], b...)
+	c.resends = 0
+
+	redial := false
+	err := c.policy.Do(func() error {
+		if redial {
+			if err := c.redial(); err != nil {
+				return err
+			}
+		}
+		redial = true
+
+		return c.write(b)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return len(b), nil
+}
+
+func (c *retryConn) Read(b []byte) (int, error) {
+	if c.reconnected {
+		c.reconnected = false
+
+		return 0, yeelight.ErrReconnected
+	}
+
+	if len(c.pending) > 0 {
+		n := copy(b, c.pending)
+		c.pending = c.pending[n:]
+
+		return n, nil
+	}
+
+	if err := c.setDeadline(); err != nil {
+		return 0, err
+	}
+
+	n, err := c.conn.Read(b)
+	if err == nil || !resendable(c.last) || !retryable(err) || c.resends+1 >= c.policy.Attempts {
+		return n, err
+	}
+
+	c.resends++
+	delay := c.policy.delay(c.resends)
+	slog.Debug("resend command", "attempt", c.resends, "delay", delay, "err", err)
+	time.Sleep(delay)
+
+	err = c.policy.Do(func() error {
+		if err := c.redial(); err != nil {
+			return err
+		}
+
+		return c.write(c.last)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	c.reconnected = false
+
+	return 0, yeelight.ErrReconnected
+}
+
+// aliveWait is how long alive waits for the bulb to close the connection.
+const aliveWait = time.Millisecond
+
+// alive tells whether the bulb kept the connection open while it was idle.
+// Bulbs close idle connections, and a command sent to a closed one may not
+// be safe to send again. Notifications read meanwhile are kept for Read.
+func (c *retryConn) alive() bool {
+	if err := c.conn.SetReadDeadline(time.Now().Add(aliveWait)); err != nil {
+		return false
+	}
+
+	buf := make([]byte, 1024)
+	for {
+		n, err := c.conn.Read(buf)
+		c.pending = append(c.pending, buf[:n]...)
+
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			return c.conn.SetReadDeadline(time.Time{}) == nil
+		}
+
+		if err != nil {
+			return false
+		}
+	}
+}
+
+func (c *retryConn) Close() error {
+	return c.conn.Close()
+}
+
+func (c *retryConn) write(b []byte) error {
+	if err := c.setDeadline(); err != nil {
+		return err
+	}
+
+	_, err := c.conn.Write(b)
+
+	return err
+}
+
+func (c *retryConn) redial() error {
+	_ = c.conn.Close()
+	c.pending = nil
+	c.reconnected = true
+
+	conn, err := c.dial()
+	if err != nil {
+		return err
+	}
+	c.conn = conn
+
+	return nil
+}
+
+func (c *retryConn) setDeadline() error {
+	if c.timeout <= 0 {
+		return nil
+	}
+
+	if err := c.conn.SetDeadline(time.Now().Add(c.timeout)); err != nil {
+		return fmt.Errorf("set deadline: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,131 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strconv"
+	"time"
+)
+
+type Job struct {
+	ID      int       `json:"id"`
+	Trigger string    `json:"trigger"`
+	Args    []string  `json:"args"`
+	Created time.Time `json:"created"`
+	LastRun time.Time `json:"last_run"`
+}
+
+type ScheduleFileStore struct {
+	jobs map[string]Job
+	dir  string
+}
+
+func NewScheduleFileStore(dir string) *ScheduleFileStore {
+	return &ScheduleFileStore{
+		jobs: make(map[string]Job),
+		dir:  dir,
+	}
+}
+
+// Init loads jobs from the file, dropping jobs loaded before, so the
+// scheduler can pick up jobs changed by other ylc runs.
+func (s *ScheduleFileStore) Init() error {
+	s.jobs = make(map[string]Job)
+
+	return readJSONFile(s.schedulesPath(), &s.jobs)
+}
+
+func (s *ScheduleFileStore) All() []Job {
+	jobs := make([]Job, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		jobs = append(jobs, job)
+	}
+
+	slices.SortFunc(jobs, func(x, y Job) int { return x.ID - y.ID })
+
+	return jobs
+}
+
+func (s *ScheduleFileStore) AllIDs() []string {
+	jobs := s.All()
+	ids := make([]string, 0, len(jobs))
+	for _, job := range jobs {
+		ids = append(ids, strconv.Itoa(job.ID))
+	}
+
+	return ids
+}
+
+var ErrJobNotFound = errors.New("not found")
+
+func (s *ScheduleFileStore) FindByID(id int) (Job, error) {
+	job, ok := s.jobs[strconv.Itoa(id)]
+	if ok {
+		return job, nil
+	}
+
+	return Job{}, ErrJobNotFound
+}
+
+func (s *ScheduleFileStore) NextID() int {
+	id := 1
+	for _, job := range s.jobs {
+		id = max(id, job.ID+1)
+	}
+
+	return id
+}
+
+func (s *ScheduleFileStore) Save(job Job) {
+	s.jobs[strconv.Itoa(job.ID)] = job
+}
+
+func (s *ScheduleFileStore) Delete(job Job) {
+	delete(s.jobs, strconv.Itoa(job.ID))
+}
+
+func (s *ScheduleFileStore) Flush() error {
+	return writeJSONFile(s.schedulesPath(), s.jobs)
+}
+
+// Reload loads jobs like Init, but waits for other ylc runs to finish
+// writing them.
+func (s *ScheduleFileStore) Reload() (err error) {
+	unlock, err := lockJSONFile(s.schedulesPath())
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, unlock())
+	}()
+
+	return s.Init()
+}
+
+// Update reloads the jobs, lets fn change them and saves them, holding a lock
+// on the file, so concurrent ylc runs do not overwrite each other's changes.
+func (s *ScheduleFileStore) Update(fn func() error) (err error) {
+	unlock, err := lockJSONFile(s.schedulesPath())
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, unlock())
+	}()
+
+	if err := s.Init(); err != nil {
+		return fmt.Errorf("load schedules: %w", err)
+	}
+
+	if err := fn(); err != nil {
+		return err
+	}
+
+	return s.Flush()
+}
+
+func (s *ScheduleFileStore) schedulesPath() string {
+	return path.Join(s.dir, "schedules.json")
+}
@@ -0,0 +1,257 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+)
+
+const timeFormat = "2006-01-02 15:04 MST"
+
+type Schedules struct {
+	store    *ScheduleFileStore
+	location *Location
+	printer  Printer
+	now      func() time.Time
+}
+
+func NewSchedules(store *ScheduleFileStore, location *Location, printer Printer) *Schedules {
+	return &Schedules{store: store, location: location, printer: printer, now: time.Now}
+}
+
+func (s *Schedules) Add(trigger string, args []string) error {
+	parsed, err := ParseTrigger(trigger, s.location)
+	if err != nil {
+		return err
+	}
+
+	var job Job
+	err = s.store.Update(func() error {
+		job = Job{
+			ID:      s.store.NextID(),
+			Trigger: trigger,
+			Args:    args,
+			Created: s.now(),
+		}
+		s.store.Save(job)
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.printer.Printf("Added job %d, next run at %s\n", job.ID, formatRun(parsed.Next(job.Created)))
+
+	return nil
+}
+
+func (s *Schedules) List() error {
+	const format = " %4s %-20s %-22s %s\n"
+
+	s.printer.Printf(format, "ID", "Trigger", "Next run", "Command")
+	for _, job := range s.store.All() {
+		next := "invalid trigger"
+		if trigger, err := ParseTrigger(job.Trigger, s.location); err == nil {
+			next = formatRun(trigger.Next(s.now()))
+		}
+
+		s.printer.Printf(format, fmt.Sprint(job.ID), job.Trigger, next, strings.Join(job.Args, " "))
+	}
+
+	return nil
+}
+
+func (s *Schedules) Remove(id int) error {
+	return s.store.Update(func() error {
+		job, err := s.store.FindByID(id)
+		if err != nil {
+			return fmt.Errorf("find job %d: %w", id, err)
+		}
+
+		s.store.Delete(job)
+
+		return nil
+	})
+}
+
+type run struct {
+	at  time.Time
+	job Job
+}
+
+// Preview prints the next count runs of all jobs without running them.
+func (s *Schedules) Preview(count int) error {
+	var runs []run
+	for _, job := range s.store.All() {
+		trigger, err := ParseTrigger(job.Trigger, s.location)
+		if err != nil {
+			return fmt.Errorf("job %d: %w", job.ID, err)
+		}
+
+		at := s.now()
+		for range count {
+			at = trigger.Next(at)
+			if at.IsZero() {
+				break
+			}
+
+			runs = append(runs, run{at: at, job: job})
+		}
+	}
+
+	slices.SortStableFunc(runs, func(x, y run) int { return x.at.Compare(y.at) })
+
+	for _, run := range runs[:min(count, len(runs))] {
+		s.printer.Printf("%s  job %d: %s\n", run.at.Format(timeFormat), run.job.ID, strings.Join(run.job.Args, " "))
+	}
+
+	return nil
+}
+
+func formatRun(at time.Time) string {
+	if at.IsZero() {
+		return "never"
+	}
+
+	return at.Format(timeFormat)
+}
+
+type Runner func(args []string) error
+
+type Scheduler struct {
+	store    *ScheduleFileStore
+	location *Location
+	run      Runner
+	grace    time.Duration
+	printer  Printer
+	now      func() time.Time
+}
+
+func NewScheduler(
+	store *ScheduleFileStore,
+	location *Location,
+	run Runner,
+	grace time.Duration,
+	printer Printer,
+) *Scheduler {
+	return &Scheduler{store: store, location: location, run: run, grace: grace, printer: printer, now: time.Now}
+}
+
+// maxWait makes the scheduler look at the jobs at least once a minute, so it
+// picks up changed jobs and clock jumps after suspend.
+const maxWait = time.Minute
+
+func (s *Scheduler) Run(ctx context.Context) error {
+	for {
+		next, err := s.RunDue()
+		if err != nil {
+			return err
+		}
+
+		wait := maxWait
+		if !next.IsZero() {
+			wait = min(max(next.Sub(s.now()), 0), maxWait)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			return nil
+		case <-timer.C:
+		}
+	}
+}
+
+// RunDue runs the jobs which are due and returns the time of the next run.
+// A run missed while the scheduler was not running is done once if it is
+// not older than the grace period, and skipped otherwise.
+func (s *Scheduler) RunDue() (time.Time, error) {
+	if err := s.store.Reload(); err != nil {
+		return time.Time{}, fmt.Errorf("load schedules: %w", err)
+	}
+
+	now := s.now()
+	var ran []int
+	var next time.Time
+
+	for _, job := range s.store.All() {
+		trigger, err := ParseTrigger(job.Trigger, s.location)
+		if err != nil {
+			slog.Warn("skip job", "job", job.ID, "err", err)
+
+			continue
+		}
+
+		last := job.LastRun
+		if last.IsZero() {
+			last = job.Created
+		}
+
+		if due := trigger.Next(last); !due.IsZero() && !due.After(now) {
+			s.runJob(job, trigger, last, now)
+			ran = append(ran, job.ID)
+		}
+
+		if at := trigger.Next(now); !at.IsZero() && (next.IsZero() || at.Before(next)) {
+			next = at
+		}
+	}
+
+	if len(ran) > 0 {
+		if err := s.markRun(ran, now); err != nil {
+			return time.Time{}, fmt.Errorf("save schedules: %w", err)
+		}
+	}
+
+	return next, nil
+}
+
+// markRun saves the last run of the jobs which ran. It updates the jobs as
+// they are in the file now, as jobs may be added or removed while they run.
+func (s *Scheduler) markRun(ids []int, at time.Time) error {
+	return s.store.Update(func() error {
+		for _, id := range ids {
+			job, err := s.store.FindByID(id)
+			if err != nil {
+				continue
+			}
+
+			job.LastRun = at
+			s.store.Save(job)
+		}
+
+		return nil
+	})
+}
+
+func (s *Scheduler) runJob(job Job, trigger Trigger, last, now time.Time) {
+	// The window is at least a minute, as runs are due on minute boundaries.
+	window := max(s.grace, time.Minute)
+
+	recent := trigger.Next(latest(last, now.Add(-window)))
+	if recent.IsZero() || recent.After(now) {
+		s.printer.Printf("Skip missed run of job %d\n", job.ID)
+
+		return
+	}
+
+	s.printer.Printf("Run job %d: %s\n", job.ID, strings.Join(job.Args, " "))
+
+	if err := s.run(job.Args); err != nil {
+		s.printer.Printf("Job %d failed: %v\n", job.ID, err)
+	}
+}
+
+func latest(x, y time.Time) time.Time {
+	if x.After(y) {
+		return x
+	}
+
+	return y
+}
@@ -0,0 +1,420 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrInvalidStatement  = errors.New("invalid statement")
+	ErrUnclosedBlock     = errors.New("block is not closed")
+	ErrUnexpectedEnd     = errors.New("unexpected }")
+	ErrUndefinedVariable = errors.New("undefined variable")
+)
+
+type stepKind int
+
+const (
+	stepCommand stepKind = iota
+	stepSet
+	stepSleep
+	stepRepeat
+	stepFor
+	stepParallel
+	stepDo
+)
+
+type scriptStep struct {
+	line  int
+	kind  stepKind
+	parts [][]wordPart
+	body  []scriptStep
+}
+
+// Script is a list of ylc commands with waits, loops and parallel blocks.
+// Variables are expanded like in a shell: not in single quotes, and a value
+// of several words in a for list, unless in double quotes, gives several
+// values:
+//
+//	set bulb pikachu
+//	repeat 3 {
+//	  power $bulb
+//	  sleep 1s
+//	}
+//	set bulbs pikachu raichu
+//	for name in $bulbs {
+//	  bright $name 50
+//	}
+//	parallel {
+//	  sunrise pikachu
+//	  do {
+//	    sleep 10m
+//	    temperature raichu 2700
+//	  }
+//	}
+type Script struct {
+	steps []scriptStep
+}
+
+func ParseScript(r io.Reader) (*Script, error) {
+	scanner := bufio.NewScanner(r)
+	line := 0
+
+	steps, closed, err := parseSteps(scanner, &line)
+	if err != nil {
+		return nil, err
+	}
+
+	if closed {
+		return nil, fmt.Errorf("line %d: %w", line, ErrUnexpectedEnd)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read script: %w", err)
+	}
+
+	return &Script{steps: steps}, nil
+}
+
+// parseSteps parses lines up to the end of the block and reports whether the
+// block was closed by }.
+func parseSteps(scanner *bufio.Scanner, line *int) ([]scriptStep, bool, error) {
+	var steps []scriptStep
+	for scanner.Scan() {
+		*line++
+
+		parts, err := splitWordParts(scanner.Text())
+		if err != nil {
+			return nil, false, fmt.Errorf("line %d: %w", *line, err)
+		}
+
+		if len(parts) == 0 {
+			continue
+		}
+
+		words := make([]string, 0, len(parts))
+		for _, word := range parts {
+			words = append(words, joinParts(word))
+		}
+
+		if len(words) == 1 && words[0] == "}" {
+			return steps, true, nil
+		}
+
+		step, err := parseStep(*line, words)
+		if err != nil {
+			return nil, false, fmt.Errorf("line %d: %w", *line, err)
+		}
+		step.parts = parts
+
+		if step.kind == stepRepeat || step.kind == stepFor || step.kind == stepParallel || step.kind == stepDo {
+			start := *line
+
+			var closed bool
+			step.body, closed, err = parseSteps(scanner, line)
+			if err != nil {
+				return nil, false, err
+			}
+
+			if !closed {
+				return nil, false, fmt.Errorf("line %d: %w", start, ErrUnclosedBlock)
+			}
+		}
+
+		steps = append(steps, step)
+	}
+
+	return steps, false, nil
+}
+
+func parseStep(line int, words []string) (scriptStep, error) {
+	step := scriptStep{line: line, kind: stepCommand}
+
+	block := words[len(words)-1] == "{"
+	switch words[0] {
+	case "set":
+		step.kind = stepSet
+		if len(words) < 3 {
+			return step, fmt.Errorf("%w: set needs a name and a value", ErrInvalidStatement)
+		}
+	case "sleep":
+		step.kind = stepSleep
+		if len(words) != 2 {
+			return step, fmt.Errorf("%w: sleep needs a duration", ErrInvalidStatement)
+		}
+	case "repeat":
+		step.kind = stepRepeat
+		if len(words) != 3 || !block {
+			return step, fmt.Errorf("%w: use repeat COUNT {", ErrInvalidStatement)
+		}
+	case "for":
+		step.kind = stepFor
+		if len(words) < 5 || words[2] != "in" || !block {
+			return step, fmt.Errorf("%w: use for NAME in VALUE... {", ErrInvalidStatement)
+		}
+	case "parallel":
+		step.kind = stepParallel
+		if len(words) != 2 || !block {
+			return step, fmt.Errorf("%w: use parallel {", ErrInvalidStatement)
+		}
+	case "do":
+		step.kind = stepDo
+		if len(words) != 2 || !block {
+			return step, fmt.Errorf("%w: use do {", ErrInvalidStatement)
+		}
+	}
+
+	if block && step.kind == stepCommand {
+		return step, fmt.Errorf("%w: unknown block %q", ErrInvalidStatement, words[0])
+	}
+
+	return step, nil
+}
+
+type scriptRun struct {
+	run     Runner
+	check   bool
+	printer Printer
+
+	mu       sync.Mutex
+	commands int
+}
+
+// Run runs the script commands with run. Commands in parallel blocks take
+// turns, while their sleeps overlap. In check mode sleeps are skipped,
+// parallel blocks run one branch after another and every loop body runs
+// once per for value but only once per repeat, even repeat 0.
+func (s *Script) Run(ctx context.Context, run Runner, check bool, printer Printer) error {
+	r := &scriptRun{run: run, check: check, printer: printer}
+	if err := r.steps(ctx, s.steps, map[string]string{}); err != nil {
+		return err
+	}
+
+	if check {
+		printer.Printf("Checked %d commands\n", r.commands)
+	}
+
+	return nil
+}
+
+func (r *scriptRun) steps(ctx context.Context, steps []scriptStep, vars map[string]string) error {
+	for _, step := range steps {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if err := r.step(ctx, step, vars); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *scriptRun) step(ctx context.Context, step scriptStep, vars map[string]string) error {
+	words, err := expandWords(step.parts, vars, false)
+	if err != nil {
+		return fmt.Errorf("line %d: %w", step.line, err)
+	}
+
+	switch step.kind {
+	case stepSet:
+		vars[words[1]] = strings.Join(words[2:], " ")
+	case stepSleep:
+		return r.sleep(ctx, step.line, words[1])
+	case stepRepeat:
+		return r.repeat(ctx, step, words[1], vars)
+	case stepFor:
+		values, err := expandWords(step.parts[3:len(step.parts)-1], vars, true)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", step.line, err)
+		}
+
+		for _, value := range values {
+			vars[words[1]] = value
+			if err := r.steps(ctx, step.body, vars); err != nil {
+				return err
+			}
+		}
+	case stepParallel:
+		return r.parallel(ctx, step.body, vars)
+	case stepDo:
+		return r.steps(ctx, step.body, vars)
+	case stepCommand:
+		r.mu.Lock()
+		r.commands++
+		err := r.run(words)
+		r.mu.Unlock()
+
+		if err != nil {
+			return fmt.Errorf("line %d: %w", step.line, err)
+		}
+	}
+
+	return nil
+}
+
+func (r *scriptRun) sleep(ctx context.Context, line int, value string) error {
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration < 0 {
+		return fmt.Errorf("line %d: %w: invalid sleep duration %q", line, ErrInvalidStatement, value)
+	}
+
+	if r.check {
+		return nil
+	}
+
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func (r *scriptRun) repeat(ctx context.Context, step scriptStep, value string, vars map[string]string) error {
+	count, err := strconv.Atoi(value)
+	if err != nil || count < 0 {
+		return fmt.Errorf("line %d: %w: invalid repeat count %q", step.line, ErrInvalidStatement, value)
+	}
+
+	if r.check {
+		count = 1
+	}
+
+	for range count {
+		if err := r.steps(ctx, step.body, vars); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// parallel runs every step of the block at the same time, each with its own
+// copy of the variables. A do block runs several steps in one branch. The
+// first failure stops the other branches.
+func (r *scriptRun) parallel(ctx context.Context, steps []scriptStep, vars map[string]string) error {
+	if r.check {
+		for _, step := range steps {
+			if err := r.steps(ctx, []scriptStep{step}, maps.Clone(vars)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errs := make([]error, len(steps))
+
+	var wg sync.WaitGroup
+	for i, step := range steps {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			errs[i] = r.steps(ctx, []scriptStep{step}, maps.Clone(vars))
+			if errs[i] != nil {
+				cancel()
+			}
+		}()
+	}
+	wg.Wait()
+
+	var failed []error
+	for _, err := range errs {
+		if err != nil && !errors.Is(err, context.Canceled) {
+			failed = append(failed, err)
+		}
+	}
+
+	if len(failed) == 0 {
+		return ctx.Err()
+	}
+
+	return errors.Join(failed...)
+}
+
+// expandWords expands the variables in the words. With split, unquoted values
+// of several words give several words, like in a shell.
+func expandWords(words [][]wordPart, vars map[string]string, split bool) ([]string, error) {
+	var undefined []string
+	expand := func(text string) string {
+		return os.Expand(text, func(name string) string {
+			value, ok := vars[name]
+			if !ok {
+				undefined = append(undefined, name)
+			}
+
+			return value
+		})
+	}
+
+	expanded := make([]string, 0, len(words))
+	for _, parts := range words {
+		var word strings.Builder
+		started := false
+		end := func() {
+			if started {
+				expanded = append(expanded, word.String())
+				word.Reset()
+				started = false
+			}
+		}
+
+		for _, part := range parts {
+			switch {
+			case part.kind == partLiteral:
+				word.WriteString(part.text)
+			case part.kind == partPlain && split:
+				value := expand(part.text)
+				if strings.TrimLeft(value, " \t") != value {
+					end()
+				}
+
+				for i, field := range strings.Fields(value) {
+					if i > 0 {
+						end()
+					}
+					word.WriteString(field)
+					started = true
+				}
+
+				if strings.TrimRight(value, " \t") != value {
+					end()
+				}
+
+				continue
+			default:
+				word.WriteString(expand(part.text))
+			}
+			started = true
+		}
+
+		if !split {
+			started = true
+		}
+		end()
+	}
+
+	if len(undefined) > 0 {
+		return nil, fmt.Errorf("%w: %q", ErrUndefinedVariable, undefined[0])
+	}
+
+	return expanded, nil
+}
@@ -0,0 +1,62 @@
+package app
+
+import (
+	"errors"
+	"path"
+	"slices"
+)
+
+type Snapshot struct {
+	Name  string               `json:"name"`
+	Bulbs map[string]BulbState `json:"bulbs"`
+}
+
+type SnapshotFileStore struct {
+	snapshots map[string]Snapshot
+	dir       string
+}
+
+func NewSnapshotFileStore(dir string) *SnapshotFileStore {
+	return &SnapshotFileStore{
+		snapshots: make(map[string]Snapshot),
+		dir:       dir,
+	}
+}
+
+func (s *SnapshotFileStore) Init() error {
+	return readJSONFile(s.snapshotsPath(), &s.snapshots)
+}
+
+func (s *SnapshotFileStore) AllNames() []string {
+	names := make([]string, 0, len(s.snapshots))
+	for name := range s.snapshots {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	return names
+}
+
+var ErrSnapshotNotFound = errors.New("not found")
+
+func (s *SnapshotFileStore) FindByName(name string) (Snapshot, error) {
+	snapshot, ok := s.snapshots[name]
+	if ok {
+		return snapshot, nil
+	}
+
+	return Snapshot{}, ErrSnapshotNotFound
+}
+
+func (s *SnapshotFileStore) Save(snapshot Snapshot) {
+	s.snapshots[snapshot.Name] = snapshot
+}
+
+func (s *SnapshotFileStore) Flush() error {
+	return writeJSONFile(s.snapshotsPath(), s.snapshots)
+}
+
+func (s *SnapshotFileStore) snapshotsPath() string {
+	return path.Join(s.dir, "snapshots.json")
+}
@@ -0,0 +1,77 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/pugkong/ylc/yeelight"
+)
+
+type Snapshots struct {
+	bulbs   *BulbFileStore
+	store   *SnapshotFileStore
+	control *Control
+}
+
+func NewSnapshots(bulbs *BulbFileStore, store *SnapshotFileStore, dialer *Dialer, printer Printer) *Snapshots {
+	return &Snapshots{
+		bulbs:   bulbs,
+		store:   store,
+		control: NewControl(bulbs, dialer, printer),
+	}
+}
+
+func (s *Snapshots) Save(name string, bulbNames []string) error {
+	if len(bulbNames) == 0 {
+		bulbNames = s.bulbs.AllNames()
+	}
+
+	snapshot := Snapshot{Name: name, Bulbs: make(map[string]BulbState, len(bulbNames))}
+	for _, bulbName := range bulbNames {
+		bulb, err := s.bulbs.FindByName(bulbName)
+		if err != nil {
+			return fmt.Errorf("find %q bulb: %w", bulbName, err)
+		}
+
+		state, err := s.control.State(bulbName)
+		if err != nil {
+			return err
+		}
+
+		snapshot.Bulbs[bulb.ID] = state
+	}
+
+	s.store.Save(snapshot)
+
+	return s.store.Flush()
+}
+
+func (s *Snapshots) Restore(name string, effect yeelight.Effect, duration int) error {
+	snapshot, err := s.store.FindByName(name)
+	if err != nil {
+		return fmt.Errorf("find %q snapshot: %w", name, err)
+	}
+
+	ids := make([]string, 0, len(snapshot.Bulbs))
+	for id := range snapshot.Bulbs {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+
+	var errs []error
+	for _, id := range ids {
+		bulb, err := s.bulbs.FindByID(id)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("find %q bulb: %w", id, err))
+
+			continue
+		}
+
+		if err := s.control.ApplyState(bulb.Name, snapshot.Bulbs[id], effect, duration); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,154 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pugkong/ylc/yeelight"
+)
+
+type ColorMode string
+
+const (
+	ColorModeRGB         ColorMode = "rgb"
+	ColorModeTemperature ColorMode = "temperature"
+	ColorModeHSV         ColorMode = "hsv"
+)
+
+type LightState struct {
+	Power            yeelight.Power `json:"power"`
+	Bright           int            `json:"bright,omitempty"`
+	ColorMode        ColorMode      `json:"color_mode,omitempty"`
+	ColorTemperature int            `json:"ct,omitempty"`
+	RGB              int            `json:"rgb,omitempty"`
+	HUE              int            `json:"hue,omitempty"`
+	Saturation       int            `json:"sat,omitempty"`
+}
+
+func (s LightState) String() string {
+	if s.Power != yeelight.PowerOn {
+		return string(yeelight.PowerOff)
+	}
+
+	parts := []string{string(s.Power)}
+	if s.Bright != 0 {
+		parts = append(parts, fmt.Sprintf("%d%%", s.Bright))
+	}
+
+	switch s.ColorMode {
+	case ColorModeRGB:
+		parts = append(parts, fmt.Sprintf("rgb %06x", s.RGB))
+	case ColorModeTemperature:
+		parts = append(parts, fmt.Sprintf("%dK", s.ColorTemperature))
+	case ColorModeHSV:
+		parts = append(parts, fmt.Sprintf("hsv %d/%d", s.HUE, s.Saturation))
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+type BulbState struct {
+	Main       LightState  `json:"main"`
+	Background *LightState `json:"background,omitempty"`
+
+	// Mode and NightLightBright are only set for bulbs with a night light.
+	Mode             string `json:"mode,omitempty"`
+	NightLightBright int    `json:"night_light_bright,omitempty"`
+}
+
+func stateFromInfo(info yeelight.BulbInfo) (BulbState, error) {
+	main, err := lightStateFromInfo(
+		info.Power,
+		info.Bright,
+		string(info.ColorMode),
+		info.ColorTemperature,
+		string(info.RGB),
+		info.HUE,
+		info.Saturation,
+	)
+	if err != nil {
+		return BulbState{}, err
+	}
+
+	state := BulbState{Main: main}
+	if info.HasNightLight() {
+		state.Mode = activeMode(info)
+		state.NightLightBright, err = optionalAtoi(info.NightLightBright, "night light bright")
+		if err != nil {
+			return BulbState{}, err
+		}
+	}
+
+	if !info.HasBackground() {
+		return state, nil
+	}
+
+	background, err := lightStateFromInfo(
+		info.BackgroundPower,
+		info.BackgroundBright,
+		string(info.BackgroundColorMode),
+		info.BackgroundColorTemperature,
+		string(info.BackgroundRGB),
+		info.BackgroundHUE,
+		info.BackgroundSaturation,
+	)
+	if err != nil {
+		return BulbState{}, fmt.Errorf("background: %w", err)
+	}
+	state.Background = &background
+
+	return state, nil
+}
+
+func lightStateFromInfo(power, bright, mode, ct, rgb, hue, sat string) (LightState, error) {
+	state := LightState{Power: yeelight.Power(power)}
+
+	var err error
+	state.Bright, err = optionalAtoi(bright, "bright")
+	if err != nil {
+		return LightState{}, err
+	}
+
+	switch {
+	case mode == yeelight.ColorModeRGB && rgb != "":
+		state.ColorMode = ColorModeRGB
+		state.RGB, err = atoi(rgb, "rgb")
+	case mode == yeelight.ColorModeTemperature && ct != "":
+		state.ColorMode = ColorModeTemperature
+		state.ColorTemperature, err = atoi(ct, "color temperature")
+	case mode == yeelight.ColorModeHSV && hue != "":
+		state.ColorMode = ColorModeHSV
+		state.HUE, err = atoi(hue, "hue")
+		if err == nil {
+			state.Saturation, err = optionalAtoi(sat, "saturation")
+		}
+	}
+	if err != nil {
+		return LightState{}, err
+	}
+
+	return state, nil
+}
+
+// optionalAtoi treats empty values, which bulbs report for unsupported
+// properties, as zero.
+func optionalAtoi(value, name string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	return atoi(value, name)
+}
+
+func atoi(value, name string) (int, error) {
+	v, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("parse %s: %w", name, err)
+	}
+
+	return v, nil
+}
+
+var ErrUnknownColorMode = errors.New("unknown color mode")
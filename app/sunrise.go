@@ -0,0 +1,95 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/pugkong/ylc/yeelight"
+)
+
+type flowStep struct {
+	share  float64
+	mode   yeelight.FlowMode
+	value  int
+	bright int
+}
+
+const (
+	deepRed    = 0xff1a00
+	deepOrange = 0xff4500
+	amber      = 0xff9a1f
+)
+
+// sunriseSteps go from deep red at the lowest brightness through amber to
+// daylight at full brightness. The shares of the whole duration add up to 1.
+var sunriseSteps = []flowStep{
+	{share: 0, mode: yeelight.FlowModeRGB, value: deepRed, bright: 1},
+	{share: 0.2, mode: yeelight.FlowModeRGB, value: deepOrange, bright: 5},
+	{share: 0.25, mode: yeelight.FlowModeRGB, value: amber, bright: 20},
+	{share: 0.25, mode: yeelight.FlowModeTemperature, value: 2700, bright: 50},
+	{share: 0.15, mode: yeelight.FlowModeTemperature, value: 4000, bright: 80},
+	{share: 0.15, mode: yeelight.FlowModeTemperature, value: 5500, bright: 100},
+}
+
+// sunsetSteps go from the current state back down to deep red.
+var sunsetSteps = []flowStep{
+	{share: 0.3, mode: yeelight.FlowModeTemperature, value: 2700, bright: 50},
+	{share: 0.3, mode: yeelight.FlowModeRGB, value: amber, bright: 20},
+	{share: 0.2, mode: yeelight.FlowModeRGB, value: deepOrange, bright: 5},
+	{share: 0.2, mode: yeelight.FlowModeRGB, value: deepRed, bright: 1},
+}
+
+var ErrFlowTooShort = errors.New("flow must last at least a minute")
+
+func flowTransitions(steps []flowStep, over time.Duration) ([]yeelight.FlowTransition, error) {
+	if over < time.Minute {
+		return nil, fmt.Errorf("%w: %s", ErrFlowTooShort, over)
+	}
+
+	transitions := make([]yeelight.FlowTransition, 0, len(steps))
+	for _, step := range steps {
+		transitions = append(transitions, yeelight.FlowTransition{
+			Duration: max(int(math.Round(step.share*float64(over.Milliseconds()))), yeelight.MinFlowDuration),
+			Mode:     step.mode,
+			Value:    step.value,
+			Bright:   step.bright,
+		})
+	}
+
+	return transitions, nil
+}
+
+// Sunrise turns lights on at the lowest brightness and brightens them to
+// daylight over the duration. The lights are turned on by the flow scene, so
+// they do not flash at the previous brightness first. The flow runs on the
+// bulb, so it does not need ylc to keep running.
+func (c *Control) Sunrise(name string, lights Lights, over time.Duration) error {
+	transitions, err := flowTransitions(sunriseSteps, over)
+	if err != nil {
+		return err
+	}
+
+	return c.control(name, lights, "start", "sunrise", func(l light) error {
+		return l.flowScene(1, yeelight.FlowActionStay, transitions)
+	})
+}
+
+// Sunset dims lights down to deep red over the duration and optionally turns
+// them off at the end.
+func (c *Control) Sunset(name string, lights Lights, over time.Duration, off bool) error {
+	transitions, err := flowTransitions(sunsetSteps, over)
+	if err != nil {
+		return err
+	}
+
+	action := yeelight.FlowActionStay
+	if off {
+		action = yeelight.FlowActionOff
+	}
+
+	return c.control(name, lights, "start", "sunset", func(l light) error {
+		return l.startFlow(1, action, transitions)
+	})
+}
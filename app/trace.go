@@ -0,0 +1,88 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/pugkong/ylc/yeelight"
+)
+
+type Tracer struct {
+	mu  sync.Mutex
+	w   io.Writer
+	now func() time.Time
+}
+
+func NewTracer(w io.Writer) *Tracer {
+	return &Tracer{w: w, now: time.Now}
+}
+
+func (t *Tracer) trace(bulb, kind string, line []byte) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	_, _ = fmt.Fprintf(t.w, "%s %s %-8s %s\n", t.now().Format(time.RFC3339Nano), bulb, kind, line)
+}
+
+func (t *Tracer) wrap(conn Conn, bulb string) Conn {
+	return &traceConn{Conn: conn, tracer: t, bulb: bulb}
+}
+
+type traceConn struct {
+	Conn
+	tracer *Tracer
+	bulb   string
+
+	written []byte
+	read    []byte
+}
+
+func (c *traceConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	c.written = c.traceLines(append(c.written, b[:n]...), func([]byte) string { return "request" })
+
+	return n, err
+}
+
+func (c *traceConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if errors.Is(err, yeelight.ErrReconnected) {
+		c.read = c.read[:0]
+	}
+	c.read = c.traceLines(append(c.read, b[:n]...), receivedKind)
+
+	return n, err
+}
+
+func (c *traceConn) traceLines(data []byte, kind func([]byte) string) []byte {
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i == -1 {
+			return data
+		}
+
+		line := bytes.TrimRight(data[:i], "\r")
+		if len(line) > 0 {
+			c.tracer.trace(c.bulb, kind(line), line)
+		}
+
+		data = data[i+1:]
+	}
+}
+
+func receivedKind(line []byte) string {
+	var message struct {
+		Method string `json:"method"`
+	}
+
+	if err := json.Unmarshal(line, &message); err == nil && message.Method != "" {
+		return "notify"
+	}
+
+	return "response"
+}
@@ -0,0 +1,104 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pugkong/ylc/cron"
+)
+
+type Trigger interface {
+	Next(after time.Time) time.Time
+}
+
+var ErrInvalidTrigger = errors.New("invalid trigger")
+
+// ParseTrigger parses a cron expression, or a sun trigger like sunrise,
+// sunset-30m or sunrise+1h which needs the location.
+func ParseTrigger(spec string, location *Location) (Trigger, error) {
+	if strings.HasPrefix(spec, sunrise) || strings.HasPrefix(spec, sunset) {
+		trigger, err := parseSunTrigger(spec, location)
+		if err != nil {
+			return nil, fmt.Errorf("parse %q trigger: %w", spec, err)
+		}
+
+		return trigger, nil
+	}
+
+	schedule, err := cron.Parse(spec)
+	if err != nil {
+		return nil, fmt.Errorf("parse %q trigger: %w", spec, err)
+	}
+
+	return schedule, nil
+}
+
+const (
+	sunrise = "sunrise"
+	sunset  = "sunset"
+)
+
+type sunTrigger struct {
+	event    string
+	offset   time.Duration
+	location *Location
+	tz       *time.Location
+}
+
+func parseSunTrigger(spec string, location *Location) (*sunTrigger, error) {
+	if location == nil {
+		return nil, ErrNoLocation
+	}
+
+	tz, err := location.timezone()
+	if err != nil {
+		return nil, err
+	}
+
+	trigger := &sunTrigger{event: sunrise, location: location, tz: tz}
+	if strings.HasPrefix(spec, sunset) {
+		trigger.event = sunset
+	}
+
+	if offset := strings.TrimPrefix(spec, trigger.event); offset != "" {
+		if offset[0] != '+' && offset[0] != '-' {
+			return nil, fmt.Errorf("%w: offset %q needs a sign", ErrInvalidTrigger, offset)
+		}
+
+		trigger.offset, err = time.ParseDuration(offset)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidTrigger, err)
+		}
+	}
+
+	return trigger, nil
+}
+
+// sunSearchDays bounds the search for the next event, which does not happen
+// for months during polar days and nights.
+const sunSearchDays = 366
+
+func (t *sunTrigger) Next(after time.Time) time.Time {
+	date := after.In(t.tz).AddDate(0, 0, -1)
+
+	for range sunSearchDays {
+		sunriseAt, sunsetAt, err := t.location.sunTimes(date)
+		if err == nil {
+			at := sunriseAt
+			if t.event == sunset {
+				at = sunsetAt
+			}
+
+			at = at.Add(t.offset).Truncate(time.Minute)
+			if at.After(after) {
+				return at
+			}
+		}
+
+		date = date.AddDate(0, 0, 1)
+	}
+
+	return time.Time{}
+}
@@ -0,0 +1,75 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/pugkong/ylc/yeelight"
+)
+
+const watchRetryDelay = 5 * time.Second
+
+// watchBulb passes props notifications of the bulb to notified until the
+// context is done, reconnecting when the connection fails. When connected is
+// not nil, it is told about every connection attempt.
+func watchBulb(
+	ctx context.Context,
+	store *BulbFileStore,
+	dialer *Dialer,
+	name string,
+	connected func(error),
+	notified func(map[string]string),
+) {
+	for ctx.Err() == nil {
+		if err := watchBulbOnce(ctx, store, dialer, name, connected, notified); err != nil && ctx.Err() == nil {
+			slog.Debug("watch bulb", "bulb", name, "err", err)
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(watchRetryDelay):
+		}
+	}
+}
+
+func watchBulbOnce(
+	ctx context.Context,
+	store *BulbFileStore,
+	dialer *Dialer,
+	name string,
+	connected func(error),
+	notified func(map[string]string),
+) error {
+	bulb, err := store.FindByName(name)
+	if err != nil {
+		return fmt.Errorf("find %q bulb: %w", name, err)
+	}
+
+	conn, err := dialer.DialWatch(bulb)
+	if connected != nil {
+		connected(err)
+	}
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	watcher := yeelight.NewWatcher(conn)
+	for {
+		props, err := watcher.Next()
+		if err != nil {
+			if connected != nil && ctx.Err() == nil {
+				connected(err)
+			}
+
+			return err
+		}
+
+		notified(props)
+	}
+}
@@ -0,0 +1,129 @@
+package app
+
+import (
+	"errors"
+	"strings"
+)
+
+var ErrUnterminatedQuote = errors.New("unterminated quote")
+
+// SplitWords splits a command line into words like a POSIX shell does, with
+// single and double quotes, backslash escapes and # comments.
+func SplitWords(line string) ([]string, error) {
+	split, err := splitWordParts(line)
+	if err != nil {
+		return nil, err
+	}
+
+	words := make([]string, 0, len(split))
+	for _, parts := range split {
+		words = append(words, joinParts(parts))
+	}
+
+	return words, nil
+}
+
+func joinParts(parts []wordPart) string {
+	var word strings.Builder
+	for _, part := range parts {
+		word.WriteString(part.text)
+	}
+
+	return word.String()
+}
+
+type wordPartKind int
+
+const (
+	// partPlain is unquoted text, whose variables expand to several words
+	// where a list is expected.
+	partPlain wordPartKind = iota
+	// partQuoted is text in double quotes, whose variables expand to one
+	// word.
+	partQuoted
+	// partLiteral is text in single quotes or escaped, where variables are
+	// not expanded.
+	partLiteral
+)
+
+type wordPart struct {
+	kind wordPartKind
+	text string
+}
+
+// splitWordParts splits a line like SplitWords, keeping how every part of the
+// words was quoted.
+func splitWordParts(line string) ([][]wordPart, error) {
+	var words [][]wordPart
+	var parts []wordPart
+	var part strings.Builder
+	kind := partPlain
+	inWord := false
+	var quote rune
+	escaped := false
+
+	// add appends r to the current part, starting a new one when the kind
+	// changes.
+	add := func(k wordPartKind, r rune) {
+		if k != kind && part.Len() > 0 {
+			parts = append(parts, wordPart{kind: kind, text: part.String()})
+			part.Reset()
+		}
+		kind = k
+		part.WriteRune(r)
+	}
+
+	endWord := func() {
+		if part.Len() > 0 || len(parts) == 0 {
+			parts = append(parts, wordPart{kind: kind, text: part.String()})
+		}
+		words = append(words, parts)
+		parts = nil
+		part.Reset()
+		kind = partPlain
+		inWord = false
+	}
+
+	for _, r := range line {
+		switch {
+		case escaped:
+			add(partLiteral, r)
+			escaped = false
+		case quote == '\'':
+			if r == quote {
+				quote = 0
+			} else {
+				add(partLiteral, r)
+			}
+		case r == '\\':
+			inWord, escaped = true, true
+		case quote == '"':
+			if r == quote {
+				quote = 0
+			} else {
+				add(partQuoted, r)
+			}
+		case r == '\'' || r == '"':
+			inWord, quote = true, r
+		case r == ' ' || r == '\t':
+			if inWord {
+				endWord()
+			}
+		case r == '#' && !inWord:
+			return words, nil
+		default:
+			inWord = true
+			add(partPlain, r)
+		}
+	}
+
+	if quote != 0 || escaped {
+		return nil, ErrUnterminatedQuote
+	}
+
+	if inWord {
+		endWord()
+	}
+
+	return words, nil
+}
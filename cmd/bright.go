@@ -10,17 +10,17 @@ import (
 )
 
 var (
-	brightBackground *bool
-	brightEffect     = &yeelight.EffectSmooth
-	brightDuration   *int
+	brightLights   = app.LightsMain
+	brightEffect   = yeelight.EffectSmooth
+	brightDuration *int
+	brightOn       bool
 )
 
 var brightCmd = &cobra.Command{
 	GroupID: controlGroup.ID,
-	Use:     "bright [bulb name] [bright]",
+	Use:     "bright [bulb name] [bright|+delta|-delta]",
 	Aliases: []string{"b"},
 	Short:   "Set bright",
-	Args:    cobra.ExactArgs(2),
 	ValidArgsFunction: func(_ *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
 		if len(args) == 0 {
 			return store.AllNames(), cobra.ShellCompDirectiveDefault
@@ -31,6 +31,7 @@ var brightCmd = &cobra.Command{
 			for i := 10; i <= 100; i += 10 {
 				brights = append(brights, strconv.Itoa(i))
 			}
+			brights = append(brights, "+10", "-10")
 
 			return brights, cobra.ShellCompDirectiveDefault
 		}
@@ -38,26 +39,41 @@ var brightCmd = &cobra.Command{
 		return nil, cobra.ShellCompDirectiveDefault
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
+		args, _ = relativeArgs(cmd.Flags(), args)
 		name := args[0]
-		value, err := strconv.Atoi(args[1])
+		control := app.NewControl(store, dialer, cmd)
+
+		relative, ok, err := parseRelativeValue(args[1])
 		if err != nil {
 			return fmt.Errorf("parse bright: %w", err)
 		}
 
-		control := app.NewControl(store, cmd)
+		if ok {
+			return control.AdjustBright(name, brightLights, relative.delta, powerOnEnabled(cmd, brightOn), *brightDuration)
+		}
 
-		if *brightBackground {
-			return control.SetBackgroundBright(name, value, *brightEffect, *brightDuration)
+		value, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("parse bright: %w", err)
 		}
 
-		return control.SetBright(name, value, *brightEffect, *brightDuration)
+		return control.SetBright(
+			name,
+			brightLights,
+			value,
+			powerOnEnabled(cmd, brightOn),
+			brightEffect,
+			*brightDuration,
+		)
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(brightCmd)
+	acceptRelativeValues(brightCmd)
 
-	brightBackground = brightCmd.Flags().Bool("bg", false, "set background bright")
-	brightCmd.Flags().VarP(newEffectValue(brightEffect), "effect", "e", "smooth or sudden")
+	addLightsFlags(brightCmd, &brightLights)
+	addOnFlag(brightCmd, &brightOn)
+	brightCmd.Flags().VarP(newEffectValue(&brightEffect), "effect", "e", "smooth or sudden")
 	brightDuration = brightCmd.Flags().IntP("duration", "d", 500, "effect duration (ms)")
 }
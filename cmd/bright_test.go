@@ -0,0 +1,266 @@
+package cmd
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/pugkong/ylc/app"
+	"github.com/pugkong/ylc/yeelight"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBrightCmd(t *testing.T) {
+	t.Run("it sets bright", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+
+		_, err := execute(t, "bright", "pikachu", "42")
+		require.NoError(t, err)
+		require.Equal(t, "42", bulb.Prop("bright"))
+		require.Equal(t, []string{"set_bright"}, bulb.Methods())
+	})
+
+	t.Run("it sets background bright", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+		bulb.SetProp("bg_power", "on")
+
+		_, err := execute(t, "b", "pikachu", "42", "--light", "bg", "-e", "sudden", "-d", "0")
+		require.NoError(t, err)
+		require.Equal(t, "42", bulb.Prop("bg_bright"))
+		require.Equal(t, "100", bulb.Prop("bright"))
+	})
+
+	t.Run("it tells bulb has no background light", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+
+		_, err := execute(t, "bright", "pikachu", "42", "--light", "both")
+		require.ErrorIs(t, err, app.ErrNoLight)
+		require.EqualError(t, err, `set "pikachu" bulb background bright: bulb has no such light`)
+		require.Equal(t, []string{"get_prop"}, bulb.Methods())
+		require.Equal(t, "100", bulb.Prop("bright"))
+	})
+
+	t.Run("it handles invalid bright", func(t *testing.T) {
+		newStoreDir(t)
+
+		_, err := execute(t, "bright", "pikachu", "bright")
+		require.ErrorContains(t, err, "parse bright")
+	})
+
+	t.Run("it rejects bright out of range", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+
+		_, err := execute(t, "bright", "pikachu", "500")
+		require.ErrorIs(t, err, yeelight.ErrOutOfRange)
+		require.ErrorContains(t, err, "bright 500 is not in 1-100")
+		require.Empty(t, bulb.Methods())
+	})
+
+	t.Run("it rejects negative duration", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+
+		_, err := execute(t, "bright", "pikachu", "42", "-e", "sudden", "-d", "-1")
+		require.ErrorIs(t, err, yeelight.ErrOutOfRange)
+		require.Empty(t, bulb.Methods())
+	})
+
+	t.Run("it clamps bright", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+
+		_, err := execute(t, "bright", "pikachu", "500", "-d", "10", "--clamp")
+		require.NoError(t, err)
+		require.Equal(t, "100", bulb.Prop("bright"))
+	})
+
+	t.Run("it handles invalid effect", func(t *testing.T) {
+		newStoreDir(t)
+
+		_, err := execute(t, "bright", "pikachu", "42", "--effect", "fancy")
+		require.ErrorContains(t, err, "unknown effect")
+	})
+
+	t.Run("it handles unknown bulb", func(t *testing.T) {
+		newStoreDir(t)
+
+		_, err := execute(t, "bright", "pikachu", "42")
+		require.ErrorIs(t, err, app.ErrBulbNotFound)
+	})
+
+	t.Run("it handles bulb error", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+		bulb.SetError("set_bright", -1, "client quota exceeded")
+
+		_, err := execute(t, "bright", "pikachu", "42")
+		require.ErrorIs(t, err, yeelight.ErrBulbResponse)
+		require.ErrorIs(t, err, yeelight.ErrQuotaExceeded)
+		require.EqualError(t, err, `set "pikachu" bulb bright: bulb command quota exceeded; retry in a minute or use --wait: bulb error: client quota exceeded`)
+	})
+
+	t.Run("it tells to power on bulb", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+		bulb.SetError("set_bright", -1, "device power off")
+
+		_, err := execute(t, "bright", "pikachu", "42")
+		require.ErrorIs(t, err, yeelight.ErrNotPoweredOn)
+		require.EqualError(t, err, `set "pikachu" bulb bright: bulb is off; use --on to power it on first: bulb error: device power off`)
+	})
+
+	t.Run("it keeps unknown bulb error", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+		bulb.SetError("set_bright", -1, "something odd")
+
+		_, err := execute(t, "bright", "pikachu", "42")
+		require.ErrorIs(t, err, yeelight.ErrBulbResponse)
+		require.EqualError(t, err, `set "pikachu" bulb bright: bulb error: something odd`)
+	})
+}
+
+func TestBrightCmd_relative(t *testing.T) {
+	t.Run("it adjusts bright", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+		bulb.SetProp("bright", "50")
+
+		_, err := execute(t, "bright", "pikachu", "+10")
+		require.NoError(t, err)
+		require.Equal(t, "60", bulb.Prop("bright"))
+
+		_, err = execute(t, "bright", "pikachu", "-20")
+		require.NoError(t, err)
+		require.Equal(t, "40", bulb.Prop("bright"))
+		require.Equal(t, []string{"adjust_bright", "adjust_bright"}, bulb.Methods())
+	})
+
+	t.Run("it adjusts background bright", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+		bulb.SetProp("bg_power", "on")
+		bulb.SetProp("bg_bright", "50")
+
+		_, err := execute(t, "bright", "pikachu", "+25", "--light", "bg")
+		require.NoError(t, err)
+		require.Equal(t, "75", bulb.Prop("bg_bright"))
+	})
+
+	t.Run("it takes negative value with flags after it", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+		bulb.SetProp("bg_power", "on")
+		bulb.SetProp("bg_bright", "50")
+
+		_, err := execute(t, "bright", "pikachu", "-10", "--light", "bg")
+		require.NoError(t, err)
+		require.Equal(t, "40", bulb.Prop("bg_bright"))
+
+		_, err = execute(t, "bright", "pikachu", "--", "-10", "-l", "bg")
+		require.NoError(t, err)
+		require.Equal(t, "30", bulb.Prop("bg_bright"))
+	})
+
+	t.Run("it rejects extra args after value", func(t *testing.T) {
+		newStoreDir(t)
+
+		_, err := execute(t, "bright", "pikachu", "-10", "more")
+		require.ErrorContains(t, err, "accepts 2 arg(s), received 3")
+	})
+
+	t.Run("it shows help after value", func(t *testing.T) {
+		newStoreDir(t)
+
+		output, err := execute(t, "bright", "pikachu", "-10", "--help")
+		require.NoError(t, err)
+		require.Contains(t, output, "Usage:")
+	})
+
+	t.Run("it adjusts bright by percent", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+		bulb.SetProp("bright", "50")
+
+		_, err := execute(t, "bright", "pikachu", "-20%")
+		require.NoError(t, err)
+		require.Equal(t, "30", bulb.Prop("bright"))
+		require.Equal(t, []string{"adjust_bright"}, bulb.Methods())
+	})
+
+	t.Run("it takes flags between bulb name and value", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+		bulb.SetProp("bright", "50")
+
+		_, err := execute(t, "bright", "pikachu", "-e", "sudden", "42")
+		require.NoError(t, err)
+		require.Equal(t, "42", bulb.Prop("bright"))
+
+		_, err = execute(t, "bright", "pikachu", "-d", "-1", "+10")
+		require.ErrorIs(t, err, yeelight.ErrOutOfRange)
+
+		_, err = execute(t, "bright", "pikachu", "--duration", "0", "-10")
+		require.NoError(t, err)
+		require.Equal(t, "32", bulb.Prop("bright"))
+	})
+
+	t.Run("it powers on bulb before adjusting", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+		bulb.SetProp("power", "off")
+
+		_, err := execute(t, "bright", "pikachu", "+10", "--on")
+		require.NoError(t, err)
+		require.Equal(t, []string{"get_prop", "set_power", "adjust_bright"}, bulb.Methods())
+		require.Equal(t, "on", bulb.Prop("power"))
+	})
+
+	t.Run("it handles invalid relative bright", func(t *testing.T) {
+		newStoreDir(t)
+
+		_, err := execute(t, "bright", "pikachu", "+ten")
+		require.ErrorContains(t, err, "parse bright")
+	})
+	t.Run("it powers on bulb first", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+		bulb.SetProp("power", "off")
+
+		_, err := execute(t, "bright", "pikachu", "42", "--on")
+		require.NoError(t, err)
+		require.Equal(t, []string{"get_prop", "set_power", "set_bright"}, bulb.Methods())
+		require.Equal(t, "on", bulb.Prop("power"))
+		require.Equal(t, "42", bulb.Prop("bright"))
+	})
+
+	t.Run("it skips power on for lit bulb", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+
+		_, err := execute(t, "bright", "pikachu", "42", "--on")
+		require.NoError(t, err)
+		require.Equal(t, []string{"get_prop", "set_bright"}, bulb.Methods())
+	})
+
+	t.Run("it powers on bulb by config default", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+		bulb.SetProp("power", "off")
+		require.NoError(t, os.WriteFile(path.Join(dir, "config.json"), []byte(`{"power_on":true}`), 0o600))
+
+		_, err := execute(t, "bright", "pikachu", "42")
+		require.NoError(t, err)
+		require.Equal(t, "on", bulb.Prop("power"))
+
+		bulb.SetProp("power", "off")
+
+		_, err = execute(t, "bright", "pikachu", "42", "--on=false")
+		require.NoError(t, err)
+		require.Equal(t, "off", bulb.Prop("power"))
+	})
+}
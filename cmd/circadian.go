@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/pugkong/ylc/app"
+	"github.com/spf13/cobra"
+)
+
+var (
+	circadianBright   *bool
+	circadianInterval *time.Duration
+	circadianPause    *time.Duration
+	circadianOnce     *bool
+)
+
+var circadianCmd = &cobra.Command{
+	GroupID: controlGroup.ID,
+	Use:     "circadian [bulb name]...",
+	Short:   "Follow the day with color temperature until interrupted, pausing for bulbs changed by hand",
+	ValidArgsFunction: func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return store.AllNames(), cobra.ShellCompDirectiveDefault
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		circadianConfig := config.Circadian
+		if cmd.Flags().Changed("interval") {
+			circadianConfig.Interval = app.Duration(*circadianInterval)
+		}
+
+		if cmd.Flags().Changed("pause") {
+			circadianConfig.Pause = app.Duration(*circadianPause)
+		}
+
+		circadian, err := app.NewCircadian(store, dialer, circadianConfig, *circadianBright, cmd)
+		if err != nil {
+			return err
+		}
+
+		if *circadianOnce {
+			return circadian.Apply(args)
+		}
+
+		ctx, stop := signal.NotifyContext(cmd.Context(), syscall.SIGINT, syscall.SIGTERM)
+		defer stop()
+
+		return circadian.Run(ctx, args)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(circadianCmd)
+
+	circadianBright = circadianCmd.Flags().Bool("bright", false, "follow the brightness of the curve too")
+	circadianInterval = circadianCmd.Flags().Duration("interval", time.Duration(app.DefaultCircadianConfig.Interval),
+		"time between updates, also the transition duration (default from config circadian.interval)")
+	circadianPause = circadianCmd.Flags().Duration("pause", time.Duration(app.DefaultCircadianConfig.Pause),
+		"leave a bulb changed by hand alone for this long (default from config circadian.pause)")
+	circadianOnce = circadianCmd.Flags().Bool("once", false, "update bulbs once and exit")
+}
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pugkong/ylc/app"
+	"github.com/pugkong/ylc/yeelight"
+	"github.com/stretchr/testify/require"
+)
+
+const flatCurveConfig = `{"circadian":{"curve":[{"at":"00:00","ct":3000,"bright":50}]}}`
+
+func TestCircadianCmd(t *testing.T) {
+	t.Run("it sets color temperature from the curve once", func(t *testing.T) {
+		dir := newStoreDir(t)
+		saveConfig(t, dir, flatCurveConfig)
+		bulb := newStoredBulb(t, dir, "pikachu")
+
+		_, err := execute(t, "circadian", "pikachu", "--once")
+		require.NoError(t, err)
+		require.Equal(t, "3000", bulb.Prop("ct"))
+		require.Equal(t, "100", bulb.Prop("bright"))
+	})
+
+	t.Run("it sets brightness from the curve", func(t *testing.T) {
+		dir := newStoreDir(t)
+		saveConfig(t, dir, flatCurveConfig)
+		bulb := newStoredBulb(t, dir, "pikachu")
+
+		_, err := execute(t, "circadian", "--once", "--bright")
+		require.NoError(t, err)
+		require.Equal(t, "3000", bulb.Prop("ct"))
+		require.Equal(t, "50", bulb.Prop("bright"))
+	})
+
+	t.Run("it pauses for bulbs changed by hand", func(t *testing.T) {
+		dir := newStoreDir(t)
+		saveConfig(t, dir, flatCurveConfig)
+		bulb := newStoredBulb(t, dir, "pikachu")
+
+		ctx, cancel := context.WithCancel(context.Background())
+		circadianCmd.SetContext(ctx)
+		t.Cleanup(func() { circadianCmd.SetContext(context.Background()) })
+
+		done := make(chan struct{})
+		var output string
+		var err error
+		go func() {
+			defer close(done)
+			output, err = execute(t, "circadian", "pikachu", "--interval", "50ms")
+		}()
+
+		require.Eventually(t, func() bool {
+			return bulb.Prop("ct") == "3000" && bulb.Connections() == 1
+		}, time.Second, 10*time.Millisecond)
+
+		conn, dialErr := net.Dial("tcp", bulb.Addr())
+		require.NoError(t, dialErr)
+		require.NoError(t, yeelight.NewController(conn).ColorTemperature(5000, yeelight.EffectSudden, 0))
+		require.NoError(t, conn.Close())
+
+		time.Sleep(200 * time.Millisecond)
+		cancel()
+		<-done
+
+		require.NoError(t, err)
+		require.Contains(t, output, `Pause "pikachu" bulb until`)
+		require.Equal(t, "5000", bulb.Prop("ct"))
+	})
+
+	t.Run("it handles invalid curve", func(t *testing.T) {
+		dir := newStoreDir(t)
+		saveConfig(t, dir, `{"circadian":{"curve":[{"at":"00:00","ct":9000,"bright":50}]}}`)
+
+		_, err := execute(t, "circadian", "--once")
+		require.ErrorIs(t, err, app.ErrInvalidCurve)
+	})
+
+	t.Run("it rejects invalid interval", func(t *testing.T) {
+		newStoreDir(t)
+
+		_, err := execute(t, "circadian", "--interval", "0s")
+		require.ErrorIs(t, err, app.ErrInvalidCircadianInterval)
+	})
+
+	t.Run("it rejects invalid interval from config", func(t *testing.T) {
+		dir := newStoreDir(t)
+		saveConfig(t, dir, `{"circadian":{"interval":"-1m"}}`)
+
+		_, err := execute(t, "circadian", "--once")
+		require.ErrorIs(t, err, app.ErrInvalidCircadianInterval)
+	})
+
+	t.Run("it rejects negative pause", func(t *testing.T) {
+		newStoreDir(t)
+
+		_, err := execute(t, "circadian", "--pause", "-1m")
+		require.ErrorIs(t, err, app.ErrInvalidCircadianPause)
+	})
+
+	t.Run("it handles unknown bulb", func(t *testing.T) {
+		newStoreDir(t)
+
+		_, err := execute(t, "circadian", "pikachu", "--once")
+		require.ErrorIs(t, err, app.ErrBulbNotFound)
+	})
+}
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"github.com/pugkong/ylc/app"
+	"github.com/pugkong/ylc/yeelight"
+	"github.com/spf13/cobra"
+)
+
+var defaultCmd = &cobra.Command{
+	GroupID: controlGroup.ID,
+	Use:     "default",
+	Short:   "Manage bulb power on state",
+}
+
+var (
+	defaultSaveLights = app.LightsMain
+	defaultSavePreset *string
+)
+
+var defaultSaveCmd = &cobra.Command{
+	Use:   "save [bulb name]",
+	Short: "Save current state as power on default",
+	Args:  cobra.ExactArgs(1),
+	ValidArgsFunction: func(_ *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return store.AllNames(), cobra.ShellCompDirectiveDefault
+		}
+
+		return nil, cobra.ShellCompDirectiveDefault
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+
+		if *defaultSavePreset != "" {
+			// Sudden effect makes sure the bulb reached the preset state before it is saved.
+			err := app.NewPresets(store, presets, dialer, cmd).Apply(*defaultSavePreset, name, yeelight.EffectSudden, 0)
+			if err != nil {
+				return err
+			}
+		}
+
+		return app.NewControl(store, dialer, cmd).SaveDefault(name, defaultSaveLights)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(defaultCmd)
+	defaultCmd.AddCommand(defaultSaveCmd)
+
+	addLightsFlags(defaultSaveCmd, &defaultSaveLights)
+	defaultSavePreset = defaultSaveCmd.Flags().String("preset", "", "apply preset before saving")
+	_ = defaultSaveCmd.RegisterFlagCompletionFunc(
+		"preset",
+		func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+			return presets.AllNames(), cobra.ShellCompDirectiveDefault
+		},
+	)
+}
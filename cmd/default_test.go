@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/pugkong/ylc/app"
+	"github.com/pugkong/ylc/yeelight"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultCmd(t *testing.T) {
+	t.Run("it saves default", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+
+		_, err := execute(t, "default", "save", "pikachu")
+		require.NoError(t, err)
+		require.Equal(t, []string{"set_default"}, bulb.Methods())
+	})
+
+	t.Run("it saves background default", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+		bulb.SetProp("bg_power", "on")
+
+		_, err := execute(t, "default", "save", "pikachu", "--light", "bg")
+		require.NoError(t, err)
+		require.Equal(t, []string{"get_prop", "bg_set_default"}, bulb.Methods())
+	})
+
+	t.Run("it applies preset before saving default", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+
+		_, err := execute(t, "preset", "save", "warm", "--bright", "80", "--temperature", "2700")
+		require.NoError(t, err)
+
+		_, err = execute(t, "default", "save", "pikachu", "--preset", "warm")
+		require.NoError(t, err)
+		require.Equal(t, []string{"set_power", "set_ct_abx", "set_bright", "set_default"}, bulb.Methods())
+		require.Equal(t, "2700", bulb.Prop("ct"))
+		require.Equal(t, "80", bulb.Prop("bright"))
+	})
+
+	t.Run("it handles unknown preset", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+
+		_, err := execute(t, "default", "save", "pikachu", "--preset", "warm")
+		require.ErrorIs(t, err, app.ErrPresetNotFound)
+		require.Empty(t, bulb.Methods())
+	})
+
+	t.Run("it handles bulb error", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+		bulb.SetError("set_default", -1, "method not supported")
+
+		_, err := execute(t, "default", "save", "pikachu")
+		require.ErrorIs(t, err, yeelight.ErrMethodNotSupported)
+		require.EqualError(t, err, `save "pikachu" bulb default: bulb does not support this command: bulb error: method not supported`)
+	})
+}
@@ -20,7 +20,7 @@ var deleteCmd = &cobra.Command{
 		return nil, cobra.ShellCompDirectiveDefault
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return app.NewManager(store, pokemon.NewNames(), cmd).Delete(args[0])
+		return app.NewManager(store, dialer, pokemon.NewNames(), cmd).Delete(args[0])
 	},
 }
 
@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/pugkong/ylc/app"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeleteCmd(t *testing.T) {
+	t.Run("it deletes bulb", func(t *testing.T) {
+		dir := newStoreDir(t)
+		saveBulbs(t, dir,
+			app.Bulb{ID: "0x01", Name: "woobat", Addr: "192.168.1.2:55443"},
+			app.Bulb{ID: "0x02", Name: "xatu", Addr: "192.168.1.3:55443"},
+		)
+
+		_, err := execute(t, "delete", "woobat")
+		require.NoError(t, err)
+		require.Equal(t, []app.Bulb{{ID: "0x02", Name: "xatu", Addr: "192.168.1.3:55443"}}, loadBulbs(t, dir))
+	})
+
+	t.Run("it handles unknown bulb", func(t *testing.T) {
+		newStoreDir(t)
+
+		_, err := execute(t, "delete", "woobat")
+		require.ErrorIs(t, err, app.ErrBulbNotFound)
+	})
+}
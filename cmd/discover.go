@@ -5,11 +5,13 @@ import (
 
 	"github.com/pugkong/ylc/app"
 	"github.com/pugkong/ylc/pokemon"
+	"github.com/pugkong/ylc/yeelight"
 	"github.com/spf13/cobra"
 )
 
 var (
 	discoverListen   *string
+	discoverAddr     *string
 	discoverDuration *time.Duration
 )
 
@@ -20,9 +22,9 @@ var discoverCmd = &cobra.Command{
 	Short:   "Discover new or update knows bulbs",
 	Args:    cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, _ []string) error {
-		manager := app.NewManager(store, pokemon.NewNames(), cmd)
+		manager := app.NewManager(store, dialer, pokemon.NewNames(), cmd)
 
-		return manager.Discover(*discoverListen, *discoverDuration)
+		return manager.Discover(*discoverListen, *discoverAddr, *discoverDuration)
 	},
 }
 
@@ -30,5 +32,6 @@ func init() {
 	rootCmd.AddCommand(discoverCmd)
 
 	discoverListen = discoverCmd.Flags().StringP("listen", "l", ":0", "address to listen")
+	discoverAddr = discoverCmd.Flags().StringP("addr", "a", yeelight.DiscoverAddr, "address to send discover message")
 	discoverDuration = discoverCmd.Flags().DurationP("duration", "d", time.Second, "time to listen")
 }
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/pugkong/ylc/app"
+	"github.com/pugkong/ylc/yeelight/yeelighttest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiscoverCmd(t *testing.T) {
+	t.Run("it saves discovered bulbs", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb1 := newBulb(t, "0x01")
+		bulb2 := newBulb(t, "0x02")
+
+		discovery, err := yeelighttest.NewDiscovery(bulb1, bulb2)
+		require.NoError(t, err)
+		t.Cleanup(func() { require.NoError(t, discovery.Close()) })
+
+		output, err := execute(t, "discover", "--addr", discovery.Addr(), "--duration", "200ms")
+		require.NoError(t, err)
+
+		bulbs := loadBulbs(t, dir)
+		require.Len(t, bulbs, 2)
+		for _, bulb := range bulbs {
+			require.NotEmpty(t, bulb.Name)
+			require.Contains(t, output, bulb.Name)
+		}
+
+		require.ElementsMatch(t, []string{bulb1.ID, bulb2.ID}, []string{bulbs[0].ID, bulbs[1].ID})
+		require.Contains(t, output, bulb1.Addr())
+		require.Contains(t, output, bulb2.Addr())
+	})
+
+	t.Run("it updates address and keeps name of known bulb", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newBulb(t, "0x01")
+		saveBulbs(t, dir, app.Bulb{ID: bulb.ID, Name: "pikachu", Addr: "192.168.1.2:55443"})
+
+		discovery, err := yeelighttest.NewDiscovery(bulb)
+		require.NoError(t, err)
+		t.Cleanup(func() { require.NoError(t, discovery.Close()) })
+
+		_, err = execute(t, "discover", "--addr", discovery.Addr(), "--duration", "200ms")
+		require.NoError(t, err)
+
+		require.Equal(t, []app.Bulb{{ID: bulb.ID, Name: "pikachu", Addr: bulb.Addr()}}, loadBulbs(t, dir))
+	})
+
+	t.Run("it handles invalid listen address", func(t *testing.T) {
+		newStoreDir(t)
+
+		_, err := execute(t, "discover", "--listen", "invalid", "--duration", "10ms")
+		require.ErrorContains(t, err, `listen "invalid" udp`)
+	})
+}
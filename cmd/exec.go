@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var nested bool
+
+// executeNested runs a ylc command line inside the running ylc. The nested
+// command reloads the stores, unless the shell runs it, and shares logging,
+// trace and record outputs.
+func executeNested(args []string) error {
+	wasNested := nested
+	nested = true
+	silenceUsage, silenceErrors := rootCmd.SilenceUsage, rootCmd.SilenceErrors
+	rootCmd.SilenceUsage, rootCmd.SilenceErrors = true, true
+
+	defer func() {
+		nested = wasNested
+		rootCmd.SilenceUsage, rootCmd.SilenceErrors = silenceUsage, silenceErrors
+	}()
+
+	resetFlags(rootCmd)
+	rootCmd.SetArgs(args)
+	_, err := rootCmd.ExecuteC()
+
+	return err
+}
+
+// validateNested checks that the command line can run inside another
+// command. Commands running until interrupted or needing a terminal can not,
+// neither can the excluded ones.
+func validateNested(args []string, errNotNestable error, excluded ...*cobra.Command) error {
+	found, _, err := rootCmd.Find(args)
+	if err != nil || found == rootCmd {
+		return fmt.Errorf("%w: %q", ErrUnknownCommand, args[0])
+	}
+
+	standalone := append([]*cobra.Command{schedulerCmd, circadianCmd, shellCmd, tuiCmd, exporterCmd}, excluded...)
+	for c := found; c != nil; c = c.Parent() {
+		if slices.Contains(standalone, c) {
+			return fmt.Errorf("%w: %q", errNotNestable, c.Name())
+		}
+	}
+
+	return nil
+}
+
+func resetFlags(cmd *cobra.Command) {
+	cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+		_ = flag.Value.Set(flag.DefValue)
+		flag.Changed = false
+	})
+
+	for _, child := range cmd.Commands() {
+		resetFlags(child)
+	}
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/pugkong/ylc/app"
+	"github.com/spf13/cobra"
+)
+
+var (
+	exporterListen   *string
+	exporterInterval *time.Duration
+)
+
+var ErrInvalidExporterInterval = errors.New("interval must be positive")
+
+var exporterCmd = &cobra.Command{
+	GroupID: manageGroup.ID,
+	Use:     "exporter",
+	Short:   "Serve metrics of known bulbs for Prometheus until interrupted",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) (err error) {
+		if *exporterInterval <= 0 {
+			return fmt.Errorf("%w: %s", ErrInvalidExporterInterval, *exporterInterval)
+		}
+
+		dialer.Pool = app.NewConnPool()
+		defer func() {
+			if closeErr := dialer.Pool.Close(); closeErr != nil {
+				err = errors.Join(err, fmt.Errorf("close bulb connections: %w", closeErr))
+			}
+		}()
+
+		exporter := app.NewExporter(store, dialer, *exporterInterval, cmd)
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("/metrics", func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+			if err := exporter.WriteMetrics(w); err != nil {
+				slog.Warn("write metrics", "err", err)
+			}
+		})
+
+		listener, err := net.Listen("tcp", *exporterListen)
+		if err != nil {
+			return fmt.Errorf("listen %q: %w", *exporterListen, err)
+		}
+
+		server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+		ctx, stop := signal.NotifyContext(cmd.Context(), syscall.SIGINT, syscall.SIGTERM)
+		defer stop()
+
+		var wg sync.WaitGroup
+		defer wg.Wait()
+
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			exporter.Run(ctx)
+		}()
+		go func() {
+			defer wg.Done()
+			<-ctx.Done()
+			_ = server.Shutdown(context.Background())
+		}()
+
+		cmd.Printf("Serve metrics on http://%s/metrics\n", listener.Addr())
+
+		if err := server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+			stop()
+
+			return fmt.Errorf("serve metrics: %w", err)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(exporterCmd)
+
+	exporterListen = exporterCmd.Flags().String("listen", ":9569", "address to serve metrics on")
+	exporterInterval = exporterCmd.Flags().Duration("interval", time.Minute, "time between bulb state queries")
+}
@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pugkong/ylc/app"
+	"github.com/pugkong/ylc/yeelight"
+	"github.com/stretchr/testify/require"
+)
+
+// startExporter runs the exporter on a free address until the test ends and
+// returns its metrics URL.
+func startExporter(t *testing.T, args ...string) string {
+	t.Helper()
+
+	addr := unreachableAddr(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	exporterCmd.SetContext(ctx)
+
+	done := make(chan error)
+	go func() {
+		_, err := execute(t, append([]string{"exporter", "--listen", addr}, args...)...)
+		done <- err
+	}()
+
+	t.Cleanup(func() {
+		cancel()
+		require.NoError(t, <-done)
+		exporterCmd.SetContext(context.Background())
+	})
+
+	return "http://" + addr + "/metrics"
+}
+
+func getMetrics(t *testing.T, url string) string {
+	t.Helper()
+
+	response, err := http.Get(url)
+	if err != nil {
+		return ""
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	require.NoError(t, err)
+
+	return string(body)
+}
+
+func TestExporterCmd(t *testing.T) {
+	t.Run("it serves bulb metrics", func(t *testing.T) {
+		dir := newStoreDir(t)
+		newStoredBulb(t, dir, "pikachu")
+
+		url := startExporter(t)
+
+		var metrics string
+		require.Eventually(t, func() bool {
+			metrics = getMetrics(t, url)
+
+			return metrics != "" && !containsLine(metrics, `ylc_bulb_query_duration_seconds_count{bulb="pikachu"} 0`)
+		}, time.Second, 10*time.Millisecond)
+
+		require.Contains(t, metrics, "# TYPE ylc_bulb_power gauge\n")
+		require.Contains(t, metrics, `ylc_bulb_reachable{bulb="pikachu"} 1`)
+		require.Contains(t, metrics, `ylc_bulb_power{bulb="pikachu"} 1`)
+		require.Contains(t, metrics, `ylc_bulb_bright_percent{bulb="pikachu"} 100`)
+		require.Contains(t, metrics, `ylc_bulb_color_temperature_kelvin{bulb="pikachu"} 4000`)
+		require.Contains(t, metrics, `ylc_bulb_last_seen_timestamp_seconds{bulb="pikachu"} `)
+		require.Contains(t, metrics, `ylc_bulb_query_duration_seconds_bucket{bulb="pikachu",le="+Inf"} 1`)
+		require.Contains(t, metrics, `ylc_bulb_query_duration_seconds_count{bulb="pikachu"} 1`)
+		require.Contains(t, metrics, `ylc_bulb_query_errors_total{bulb="pikachu"} 0`)
+		require.Contains(t, metrics, `ylc_bulb_watch_disconnects_total{bulb="pikachu"} 0`)
+	})
+
+	t.Run("it follows bulb notifications", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+
+		url := startExporter(t)
+
+		require.Eventually(t, func() bool {
+			return bulb.Connections() == 2 && containsLine(getMetrics(t, url), `ylc_bulb_power{bulb="pikachu"} 1`)
+		}, time.Second, 10*time.Millisecond)
+
+		conn, err := net.Dial("tcp", bulb.Addr())
+		require.NoError(t, err)
+		require.NoError(t, yeelight.NewController(conn).RGB(0x102030, yeelight.EffectSudden, 0))
+		require.NoError(t, conn.Close())
+
+		require.Eventually(t, func() bool {
+			metrics := getMetrics(t, url)
+
+			return containsLine(metrics, `ylc_bulb_rgb{bulb="pikachu",component="red"} 16`) &&
+				containsLine(metrics, `ylc_bulb_rgb{bulb="pikachu",component="green"} 32`) &&
+				containsLine(metrics, `ylc_bulb_rgb{bulb="pikachu",component="blue"} 48`)
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("it counts errors of unreachable bulbs", func(t *testing.T) {
+		dir := newStoreDir(t)
+		saveBulbs(t, dir, app.Bulb{ID: "0x01", Name: "pikachu", Addr: unreachableAddr(t)})
+
+		url := startExporter(t, "--attempts", "1")
+
+		require.Eventually(t, func() bool {
+			metrics := getMetrics(t, url)
+
+			return containsLine(metrics, `ylc_bulb_reachable{bulb="pikachu"} 0`) &&
+				!containsLine(metrics, `ylc_bulb_query_errors_total{bulb="pikachu"} 0`) &&
+				!containsLine(metrics, `ylc_bulb_watch_disconnects_total{bulb="pikachu"} 0`)
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("it reports failed queries of connected bulb", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+		bulb.SetError("get_prop", -1, "something odd")
+
+		url := startExporter(t, "--attempts", "1")
+
+		require.Eventually(t, func() bool {
+			return bulb.Connections() == 2 &&
+				!containsLine(getMetrics(t, url), `ylc_bulb_query_errors_total{bulb="pikachu"} 0`)
+		}, time.Second, 10*time.Millisecond)
+
+		conn, err := net.Dial("tcp", bulb.Addr())
+		require.NoError(t, err)
+		require.NoError(t, yeelight.NewController(conn).Bright(42, yeelight.EffectSudden, 0))
+		require.NoError(t, conn.Close())
+
+		var metrics string
+		require.Eventually(t, func() bool {
+			metrics = getMetrics(t, url)
+
+			return strings.Contains(metrics, `ylc_bulb_last_seen_timestamp_seconds{bulb="pikachu"} `)
+		}, time.Second, 10*time.Millisecond)
+
+		require.Contains(t, metrics, `ylc_bulb_reachable{bulb="pikachu"} 0`)
+		require.Contains(t, metrics, `ylc_bulb_watch_disconnects_total{bulb="pikachu"} 0`)
+		require.NotContains(t, metrics, `ylc_bulb_query_errors_total{bulb="pikachu"} 0`)
+		require.Contains(t, metrics, `ylc_bulb_query_duration_seconds_count{bulb="pikachu"} 0`)
+		require.Contains(t, metrics, `ylc_bulb_bright_percent{bulb="pikachu"} 42`)
+		require.NotContains(t, metrics, `ylc_bulb_power{bulb="pikachu"}`)
+	})
+
+	t.Run("it rejects invalid interval", func(t *testing.T) {
+		newStoreDir(t)
+
+		_, err := execute(t, "exporter", "--interval", "0s")
+		require.ErrorIs(t, err, ErrInvalidExporterInterval)
+	})
+}
+
+func containsLine(text, line string) bool {
+	return slices.Contains(strings.Split(text, "\n"), line)
+}
@@ -5,6 +5,8 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var infoJSON *bool
+
 var infoCmd = &cobra.Command{
 	GroupID: controlGroup.ID,
 	Use:     "info [BULB NAME]",
@@ -19,10 +21,18 @@ var infoCmd = &cobra.Command{
 		return nil, cobra.ShellCompDirectiveDefault
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return app.NewControl(store, cmd).Info(args[0])
+		control := app.NewControl(store, dialer, cmd)
+
+		if *infoJSON {
+			return control.InfoJSON(args[0])
+		}
+
+		return control.Info(args[0])
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(infoCmd)
+
+	infoJSON = infoCmd.Flags().Bool("json", false, "print info as JSON")
 }
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/pugkong/ylc/app"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInfoCmd(t *testing.T) {
+	t.Run("it prints temperature mode info", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+		bulb.SetProp("bright", "42")
+		bulb.SetProp("ct", "2700")
+
+		output, err := execute(t, "info", "pikachu")
+		require.NoError(t, err)
+		require.Contains(t, output, "Power: on\nBright: 42\nColor mode: temperature\nColor temperature: 2700\n")
+	})
+
+	t.Run("it prints rgb mode info", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+		bulb.SetProp("color_mode", "1")
+		bulb.SetProp("rgb", "16711680")
+
+		output, err := execute(t, "info", "pikachu")
+		require.NoError(t, err)
+		require.Contains(t, output, "Color mode: RGB\nRGB: ff0000\n")
+	})
+
+	t.Run("it prints hsv mode info", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+		bulb.SetProp("color_mode", "3")
+		bulb.SetProp("hue", "120")
+		bulb.SetProp("sat", "50")
+
+		output, err := execute(t, "info", "pikachu")
+		require.NoError(t, err)
+		require.Contains(t, output, "Color mode: HSV\nHUE: 120\nSaturation: 50\n")
+	})
+
+	t.Run("it prints background info", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+		bulb.SetProp("bg_power", "off")
+		bulb.SetProp("bg_bright", "30")
+		bulb.SetProp("bg_lmode", "2")
+		bulb.SetProp("bg_ct", "5000")
+
+		output, err := execute(t, "info", "pikachu")
+		require.NoError(t, err)
+		require.Contains(t, output, ""+
+			"Background power: off\n"+
+			"Background bright: 30\n"+
+			"Background color mode: temperature\n"+
+			"Background color temperature: 5000\n",
+		)
+	})
+
+	t.Run("it skips background of bulb without one", func(t *testing.T) {
+		dir := newStoreDir(t)
+		newStoredBulb(t, dir, "pikachu")
+
+		output, err := execute(t, "info", "pikachu")
+		require.NoError(t, err)
+		require.Equal(t, "Power: on\nBright: 100\nColor mode: temperature\nColor temperature: 4000\n", output)
+	})
+
+	t.Run("it prints info as json", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+		bulb.SetProp("bg_power", "off")
+		bulb.SetProp("bg_bright", "30")
+		bulb.SetProp("bg_lmode", "1")
+		bulb.SetProp("bg_rgb", "255")
+
+		output, err := execute(t, "info", "pikachu", "--json")
+		require.NoError(t, err)
+		require.JSONEq(t, `{
+			"main": {"power": "on", "bright": 100, "color_mode": "temperature", "ct": 4000},
+			"background": {"power": "off", "bright": 30, "color_mode": "rgb", "rgb": 255}
+		}`, output)
+	})
+
+	t.Run("it handles unknown bulb", func(t *testing.T) {
+		newStoreDir(t)
+
+		_, err := execute(t, "info", "pikachu")
+		require.ErrorIs(t, err, app.ErrBulbNotFound)
+		require.EqualError(t, err, `find "pikachu" bulb: not found`)
+	})
+
+	t.Run("it handles unreachable bulb", func(t *testing.T) {
+		dir := newStoreDir(t)
+		saveBulbs(t, dir, app.Bulb{ID: "0x01", Name: "pikachu", Addr: unreachableAddr(t)})
+
+		_, err := execute(t, "info", "pikachu")
+		require.ErrorContains(t, err, `connect to "pikachu" bulb`)
+	})
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"strconv"
+
+	"github.com/pugkong/ylc/app"
+	"github.com/spf13/cobra"
+)
+
+type lightsValue app.Lights
+
+func newLightsValue(value *app.Lights) *lightsValue {
+	return (*lightsValue)(value)
+}
+
+func (l *lightsValue) String() string {
+	return string(*l)
+}
+
+func (l *lightsValue) Set(value string) error {
+	lights, err := app.ParseLights(value)
+	if err != nil {
+		return err
+	}
+
+	*l = lightsValue(lights)
+
+	return nil
+}
+
+func (l *lightsValue) Type() string {
+	return "light"
+}
+
+// backgroundValue keeps the deprecated --bg flag working as --light bg.
+type backgroundValue app.Lights
+
+func (b *backgroundValue) String() string {
+	return strconv.FormatBool(app.Lights(*b) == app.LightsBackground)
+}
+
+func (b *backgroundValue) Set(value string) error {
+	background, err := strconv.ParseBool(value)
+	if err != nil {
+		return err
+	}
+
+	if background {
+		*b = backgroundValue(app.LightsBackground)
+	}
+
+	return nil
+}
+
+func (b *backgroundValue) Type() string {
+	return "bool"
+}
+
+func (b *backgroundValue) IsBoolFlag() bool {
+	return true
+}
+
+func addLightsFlags(cmd *cobra.Command, value *app.Lights) {
+	cmd.Flags().VarP(newLightsValue(value), "light", "l", "main, bg or both")
+	_ = cmd.RegisterFlagCompletionFunc(
+		"light",
+		func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+			return []string{string(app.LightsMain), string(app.LightsBackground), string(app.LightsBoth)},
+				cobra.ShellCompDirectiveDefault
+		},
+	)
+
+	cmd.Flags().Var((*backgroundValue)(value), "bg", "same as --light bg")
+	cmd.Flags().Lookup("bg").NoOptDefVal = "true"
+	_ = cmd.Flags().MarkDeprecated("bg", "use --light bg instead")
+}
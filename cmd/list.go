@@ -6,6 +6,8 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var listModes *bool
+
 var listCmd = &cobra.Command{
 	GroupID: manageGroup.ID,
 	Use:     "list",
@@ -13,10 +15,18 @@ var listCmd = &cobra.Command{
 	Short:   "List known bulbs",
 	Args:    cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, _ []string) error {
-		return app.NewManager(store, pokemon.NewNames(), cmd).List()
+		manager := app.NewManager(store, dialer, pokemon.NewNames(), cmd)
+
+		if *listModes {
+			return manager.ListWithModes()
+		}
+
+		return manager.List()
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(listCmd)
+
+	listModes = listCmd.Flags().BoolP("modes", "m", false, "query bulbs and show their power mode")
 }
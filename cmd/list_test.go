@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/pugkong/ylc/app"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListCmd(t *testing.T) {
+	t.Run("it prints stored bulbs sorted by name", func(t *testing.T) {
+		dir := newStoreDir(t)
+		saveBulbs(t, dir,
+			app.Bulb{ID: "0x02", Name: "xatu", Addr: "192.168.1.3:55443"},
+			app.Bulb{ID: "0x01", Name: "woobat", Addr: "192.168.1.2:55443"},
+		)
+
+		output, err := execute(t, "list")
+		require.NoError(t, err)
+		require.Equal(t, ""+
+			"         Name            Address                 ID\n"+
+			"       woobat  192.168.1.2:55443               0x01\n"+
+			"         xatu  192.168.1.3:55443               0x02\n",
+			output,
+		)
+	})
+
+	t.Run("it prints header for empty store", func(t *testing.T) {
+		newStoreDir(t)
+
+		output, err := execute(t, "ls")
+		require.NoError(t, err)
+		require.Equal(t, "         Name            Address                 ID\n", output)
+	})
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"errors"
+
+	"github.com/pugkong/ylc/app"
+	"github.com/pugkong/ylc/yeelight"
+	"github.com/spf13/cobra"
+)
+
+var (
+	nightLightBright   *int
+	nightLightEffect   = yeelight.EffectSmooth
+	nightLightDuration *int
+)
+
+var ErrUnknownNightLightState = errors.New("night light state must be on or off")
+
+var nightLightCmd = &cobra.Command{
+	GroupID: controlGroup.ID,
+	Use:     "nightlight [bulb name] on|off",
+	Aliases: []string{"nl", "moonlight"},
+	Short:   "Switch night light (moonlight) mode",
+	Args:    cobra.ExactArgs(2),
+	ValidArgsFunction: func(_ *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return store.AllNames(), cobra.ShellCompDirectiveDefault
+		}
+
+		if len(args) == 1 {
+			return []string{"on", "off"}, cobra.ShellCompDirectiveDefault
+		}
+
+		return nil, cobra.ShellCompDirectiveDefault
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		control := app.NewControl(store, dialer, cmd)
+
+		switch args[1] {
+		case "on":
+			return control.NightLightOn(name, *nightLightBright, nightLightEffect, *nightLightDuration)
+		case "off":
+			return control.NightLightOff(name, nightLightEffect, *nightLightDuration)
+		}
+
+		return ErrUnknownNightLightState
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(nightLightCmd)
+
+	nightLightBright = nightLightCmd.Flags().IntP("bright", "b", 0, "night light bright")
+	nightLightCmd.Flags().VarP(newEffectValue(&nightLightEffect), "effect", "e", "smooth or sudden")
+	nightLightDuration = nightLightCmd.Flags().IntP("duration", "d", 500, "effect duration (ms)")
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/pugkong/ylc/app"
+	"github.com/pugkong/ylc/yeelight/yeelighttest"
+	"github.com/stretchr/testify/require"
+)
+
+func newStoredCeilingBulb(t *testing.T, dir, name string) *yeelighttest.Bulb {
+	t.Helper()
+
+	bulb := newStoredBulb(t, dir, name)
+	bulb.SetProp("active_mode", "0")
+	bulb.SetProp("nl_br", "1")
+
+	return bulb
+}
+
+func TestNightLightCmd(t *testing.T) {
+	t.Run("it switches night light on and off", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredCeilingBulb(t, dir, "pikachu")
+
+		_, err := execute(t, "nightlight", "pikachu", "on", "--bright", "20")
+		require.NoError(t, err)
+		require.Equal(t, "1", bulb.Prop("active_mode"))
+		require.Equal(t, "20", bulb.Prop("nl_br"))
+		require.Equal(t, "100", bulb.Prop("bright"))
+
+		output, err := execute(t, "info", "pikachu")
+		require.NoError(t, err)
+		require.Contains(t, output, "Mode: moonlight\nNight light bright: 20\n")
+
+		_, err = execute(t, "nl", "pikachu", "off")
+		require.NoError(t, err)
+		require.Equal(t, "0", bulb.Prop("active_mode"))
+
+		output, err = execute(t, "info", "pikachu")
+		require.NoError(t, err)
+		require.Contains(t, output, "Mode: daylight\n")
+	})
+
+	t.Run("it shows modes in list", func(t *testing.T) {
+		dir := newStoreDir(t)
+		ceiling := newBulb(t, "0x01")
+		ceiling.SetProp("active_mode", "1")
+		plain := newBulb(t, "0x02")
+		off := newBulb(t, "0x03")
+		off.SetProp("power", "off")
+		saveBulbs(t, dir,
+			app.Bulb{ID: ceiling.ID, Name: "abra", Addr: ceiling.Addr()},
+			app.Bulb{ID: plain.ID, Name: "bulbasaur", Addr: plain.Addr()},
+			app.Bulb{ID: off.ID, Name: "charmander", Addr: off.Addr()},
+			app.Bulb{ID: "0x04", Name: "ditto", Addr: unreachableAddr(t)},
+		)
+
+		output, err := execute(t, "list", "--modes")
+		require.NoError(t, err)
+		require.Regexp(t, `abra .* moonlight\n`, output)
+		require.Regexp(t, `bulbasaur .* on\n`, output)
+		require.Regexp(t, `charmander .* off\n`, output)
+		require.Regexp(t, `ditto .* unreachable\n`, output)
+	})
+
+	t.Run("it handles invalid state", func(t *testing.T) {
+		dir := newStoreDir(t)
+		newStoredCeilingBulb(t, dir, "pikachu")
+
+		_, err := execute(t, "nightlight", "pikachu", "maybe")
+		require.ErrorIs(t, err, ErrUnknownNightLightState)
+	})
+}
@@ -0,0 +1,17 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func addOnFlag(cmd *cobra.Command, value *bool) {
+	cmd.Flags().BoolVar(value, "on", false, "turn the light on first if it is off (default from config power_on)")
+}
+
+func powerOnEnabled(cmd *cobra.Command, value bool) bool {
+	if cmd.Flags().Changed("on") {
+		return value
+	}
+
+	return config.PowerOn
+}
@@ -5,7 +5,7 @@ import (
 	"github.com/spf13/cobra"
 )
 
-var powerBackground *bool
+var powerLights = app.LightsBoth
 
 var powerCmd = &cobra.Command{
 	GroupID: controlGroup.ID,
@@ -21,18 +21,12 @@ var powerCmd = &cobra.Command{
 		return nil, cobra.ShellCompDirectiveDefault
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
-		control := app.NewControl(store, cmd)
-
-		if *powerBackground {
-			return control.BackgroundToggle(args[0])
-		}
-
-		return control.PowerToggle(args[0])
+		return app.NewControl(store, dialer, cmd).PowerToggle(args[0], powerLights)
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(powerCmd)
 
-	powerBackground = powerCmd.Flags().Bool("bg", false, "toggle only background power")
+	addLightsFlags(powerCmd, &powerLights)
 }
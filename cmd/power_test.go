@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/pugkong/ylc/app"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPowerCmd(t *testing.T) {
+	t.Run("it toggles power", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+
+		_, err := execute(t, "power", "pikachu")
+		require.NoError(t, err)
+		require.Equal(t, "off", bulb.Prop("power"))
+
+		_, err = execute(t, "p", "pikachu")
+		require.NoError(t, err)
+		require.Equal(t, "on", bulb.Prop("power"))
+	})
+
+	t.Run("it toggles both lights by default", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+		bulb.SetProp("bg_power", "on")
+
+		_, err := execute(t, "power", "pikachu")
+		require.NoError(t, err)
+		require.Equal(t, "off", bulb.Prop("bg_power"))
+		require.Equal(t, "off", bulb.Prop("power"))
+		require.Equal(t, []string{"dev_toggle"}, bulb.Methods())
+	})
+
+	t.Run("it toggles selected light", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+		bulb.SetProp("bg_power", "off")
+
+		_, err := execute(t, "power", "pikachu", "--light", "bg")
+		require.NoError(t, err)
+		require.Equal(t, "on", bulb.Prop("bg_power"))
+		require.Equal(t, "on", bulb.Prop("power"))
+
+		_, err = execute(t, "power", "pikachu", "-l", "main")
+		require.NoError(t, err)
+		require.Equal(t, "on", bulb.Prop("bg_power"))
+		require.Equal(t, "off", bulb.Prop("power"))
+		require.Equal(t, []string{"get_prop", "bg_toggle", "toggle"}, bulb.Methods())
+	})
+
+	t.Run("it supports deprecated background flag", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+		bulb.SetProp("bg_power", "off")
+
+		output, err := execute(t, "power", "pikachu", "--bg")
+		require.NoError(t, err)
+		require.Contains(t, output, "Flag --bg has been deprecated, use --light bg instead")
+		require.Equal(t, "on", bulb.Prop("bg_power"))
+		require.Equal(t, "on", bulb.Prop("power"))
+	})
+
+	t.Run("it handles unknown bulb", func(t *testing.T) {
+		newStoreDir(t)
+
+		_, err := execute(t, "power", "pikachu")
+		require.ErrorIs(t, err, app.ErrBulbNotFound)
+	})
+
+	t.Run("it handles unreachable bulb", func(t *testing.T) {
+		dir := newStoreDir(t)
+		saveBulbs(t, dir, app.Bulb{ID: "0x01", Name: "pikachu", Addr: unreachableAddr(t)})
+
+		_, err := execute(t, "power", "pikachu")
+		require.ErrorContains(t, err, `connect to "pikachu" bulb`)
+	})
+}
@@ -0,0 +1,195 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/pugkong/ylc/app"
+	"github.com/pugkong/ylc/color"
+	"github.com/pugkong/ylc/yeelight"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var presetCmd = &cobra.Command{
+	GroupID: controlGroup.ID,
+	Use:     "preset",
+	Aliases: []string{"pr"},
+	Short:   "Manage and apply saved bulb states",
+}
+
+func completePresetNames(_ *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
+	if len(args) == 0 {
+		return presets.AllNames(), cobra.ShellCompDirectiveDefault
+	}
+
+	return nil, cobra.ShellCompDirectiveDefault
+}
+
+var presetSaveFrom *string
+
+var presetSaveCmd = &cobra.Command{
+	Use:               "save [preset name]",
+	Short:             "Save preset from flags or from bulb current state",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completePresetNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		p := app.NewPresets(store, presets, dialer, cmd)
+
+		if *presetSaveFrom != "" {
+			return p.SaveFromBulb(args[0], *presetSaveFrom)
+		}
+
+		main, err := presetLightState(cmd.Flags(), "")
+		if err != nil {
+			return err
+		}
+
+		preset := app.Preset{Name: args[0], State: app.BulbState{Main: main}}
+
+		if presetLightChanged(cmd.Flags(), "bg-") {
+			background, err := presetLightState(cmd.Flags(), "bg-")
+			if err != nil {
+				return err
+			}
+
+			preset.State.Background = &background
+		}
+
+		return p.Save(preset)
+	},
+}
+
+var presetLightFlags = []string{"power", "bright", "temperature", "rgb", "hue", "sat"}
+
+func addPresetLightFlags(flags *pflag.FlagSet, prefix, light string) {
+	flags.String(prefix+"power", "on", light+" power, on or off")
+	flags.Int(prefix+"bright", 0, light+" bright")
+	flags.Int(prefix+"temperature", 0, light+" color temperature")
+	flags.String(prefix+"rgb", "", light+" rgb color")
+	flags.Int(prefix+"hue", 0, light+" hsv color hue")
+	flags.Int(prefix+"sat", 0, light+" hsv color saturation")
+}
+
+func presetLightChanged(flags *pflag.FlagSet, prefix string) bool {
+	for _, name := range presetLightFlags {
+		if flags.Changed(prefix + name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+var ErrUnknownPower = errors.New("unknown power")
+
+func presetLightState(flags *pflag.FlagSet, prefix string) (app.LightState, error) {
+	var state app.LightState
+
+	power, _ := flags.GetString(prefix + "power")
+	switch power {
+	case "on":
+		state.Power = yeelight.PowerOn
+	case "off":
+		state.Power = yeelight.PowerOff
+	default:
+		return app.LightState{}, fmt.Errorf("parse %spower: %w", prefix, ErrUnknownPower)
+	}
+
+	state.Bright, _ = flags.GetInt(prefix + "bright")
+
+	switch {
+	case flags.Changed(prefix + "temperature"):
+		state.ColorMode = app.ColorModeTemperature
+		state.ColorTemperature, _ = flags.GetInt(prefix + "temperature")
+	case flags.Changed(prefix + "rgb"):
+		rgb, _ := flags.GetString(prefix + "rgb")
+		value, err := color.Parse(rgb)
+		if err != nil {
+			return app.LightState{}, fmt.Errorf("parse %srgb: %w", prefix, err)
+		}
+
+		state.ColorMode = app.ColorModeRGB
+		state.RGB = value
+	case flags.Changed(prefix+"hue") || flags.Changed(prefix+"sat"):
+		state.ColorMode = app.ColorModeHSV
+		state.HUE, _ = flags.GetInt(prefix + "hue")
+		state.Saturation, _ = flags.GetInt(prefix + "sat")
+	}
+
+	return state, nil
+}
+
+var presetListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"l", "ls"},
+	Short:   "List saved presets",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		return app.NewPresets(store, presets, dialer, cmd).List()
+	},
+}
+
+var presetDeleteCmd = &cobra.Command{
+	Use:               "delete [preset name]",
+	Aliases:           []string{"del"},
+	Short:             "Delete preset",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completePresetNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return app.NewPresets(store, presets, dialer, cmd).Delete(args[0])
+	},
+}
+
+var (
+	presetApplyEffect   = yeelight.EffectSmooth
+	presetApplyDuration *int
+)
+
+var presetApplyCmd = &cobra.Command{
+	Use:   "apply [preset name] [bulb name]",
+	Short: "Apply preset to bulb",
+	Args:  cobra.ExactArgs(2),
+	ValidArgsFunction: func(_ *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return presets.AllNames(), cobra.ShellCompDirectiveDefault
+		}
+
+		if len(args) == 1 {
+			return store.AllNames(), cobra.ShellCompDirectiveDefault
+		}
+
+		return nil, cobra.ShellCompDirectiveDefault
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return app.NewPresets(store, presets, dialer, cmd).Apply(args[0], args[1], presetApplyEffect, *presetApplyDuration)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(presetCmd)
+	presetCmd.AddCommand(presetSaveCmd, presetListCmd, presetDeleteCmd, presetApplyCmd)
+
+	presetSaveFrom = presetSaveCmd.Flags().String("from", "", "bulb name to take current state from")
+	addPresetLightFlags(presetSaveCmd.Flags(), "", "main light")
+	addPresetLightFlags(presetSaveCmd.Flags(), "bg-", "background light")
+	for _, name := range []string{"rgb", "bg-rgb"} {
+		_ = presetSaveCmd.RegisterFlagCompletionFunc(
+			name,
+			func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+				return color.Names(), cobra.ShellCompDirectiveDefault
+			},
+		)
+	}
+	presetSaveCmd.MarkFlagsMutuallyExclusive("temperature", "rgb", "hue")
+	presetSaveCmd.MarkFlagsMutuallyExclusive("temperature", "rgb", "sat")
+	presetSaveCmd.MarkFlagsMutuallyExclusive("bg-temperature", "bg-rgb", "bg-hue")
+	presetSaveCmd.MarkFlagsMutuallyExclusive("bg-temperature", "bg-rgb", "bg-sat")
+	for _, name := range presetLightFlags {
+		presetSaveCmd.MarkFlagsMutuallyExclusive("from", name)
+		presetSaveCmd.MarkFlagsMutuallyExclusive("from", "bg-"+name)
+	}
+
+	presetApplyCmd.Flags().VarP(newEffectValue(&presetApplyEffect), "effect", "e", "smooth or sudden")
+	presetApplyDuration = presetApplyCmd.Flags().IntP("duration", "d", 500, "effect duration (ms)")
+}
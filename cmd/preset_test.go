@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/pugkong/ylc/app"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPresetCmd(t *testing.T) {
+	t.Run("it saves lists and applies presets", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+		bulb.SetProp("power", "off")
+
+		_, err := execute(t, "preset", "save", "reading", "--bright", "100", "--temperature", "4000")
+		require.NoError(t, err)
+		_, err = execute(t, "preset", "save", "movie", "--bright", "10", "--rgb", "ffbf00", "--bg-power", "off")
+		require.NoError(t, err)
+
+		output, err := execute(t, "preset", "list")
+		require.NoError(t, err)
+		require.Equal(t, ""+
+			"         Name Main                             Background\n"+
+			"        movie on, 10%, rgb ffbf00              off\n"+
+			"      reading on, 100%, 4000K                  \n",
+			output,
+		)
+
+		_, err = execute(t, "preset", "apply", "movie", "pikachu")
+		require.NoError(t, err)
+		require.Equal(t, "on", bulb.Prop("power"))
+		require.Equal(t, "10", bulb.Prop("bright"))
+		require.Equal(t, "1", bulb.Prop("color_mode"))
+		require.Equal(t, "16760576", bulb.Prop("rgb"))
+		require.Equal(t, "off", bulb.Prop("bg_power"))
+
+		_, err = execute(t, "preset", "apply", "reading", "pikachu")
+		require.NoError(t, err)
+		require.Equal(t, "100", bulb.Prop("bright"))
+		require.Equal(t, "2", bulb.Prop("color_mode"))
+		require.Equal(t, "4000", bulb.Prop("ct"))
+	})
+
+	t.Run("it saves preset from bulb", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+		bulb.SetProp("bright", "30")
+		bulb.SetProp("color_mode", "3")
+		bulb.SetProp("hue", "120")
+		bulb.SetProp("sat", "80")
+
+		_, err := execute(t, "preset", "save", "forest", "--from", "pikachu")
+		require.NoError(t, err)
+
+		output, err := execute(t, "preset", "ls")
+		require.NoError(t, err)
+		require.Contains(t, output, "forest on, 30%, hsv 120/80")
+	})
+
+	t.Run("it applies night light preset", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredCeilingBulb(t, dir, "pikachu")
+		bulb.SetProp("active_mode", "1")
+		bulb.SetProp("nl_br", "15")
+
+		_, err := execute(t, "preset", "save", "night", "--from", "pikachu")
+		require.NoError(t, err)
+
+		output, err := execute(t, "preset", "ls")
+		require.NoError(t, err)
+		require.Contains(t, output, "night on, moonlight 15%")
+
+		_, err = execute(t, "nightlight", "pikachu", "off")
+		require.NoError(t, err)
+
+		_, err = execute(t, "preset", "apply", "night", "pikachu")
+		require.NoError(t, err)
+		require.Equal(t, "1", bulb.Prop("active_mode"))
+		require.Equal(t, "15", bulb.Prop("nl_br"))
+		require.Equal(t, "100", bulb.Prop("bright"))
+	})
+
+	t.Run("it deletes preset", func(t *testing.T) {
+		newStoreDir(t)
+
+		_, err := execute(t, "preset", "save", "reading", "--bright", "100")
+		require.NoError(t, err)
+
+		_, err = execute(t, "preset", "delete", "reading")
+		require.NoError(t, err)
+
+		output, err := execute(t, "preset", "list")
+		require.NoError(t, err)
+		require.NotContains(t, output, "reading")
+	})
+
+	t.Run("it rejects conflicting color flags", func(t *testing.T) {
+		newStoreDir(t)
+
+		_, err := execute(t, "preset", "save", "reading", "--temperature", "4000", "--rgb", "ff0000")
+		require.ErrorContains(t, err, "if any flags in the group")
+	})
+
+	t.Run("it handles invalid power", func(t *testing.T) {
+		newStoreDir(t)
+
+		_, err := execute(t, "preset", "save", "reading", "--power", "maybe")
+		require.ErrorIs(t, err, ErrUnknownPower)
+	})
+
+	t.Run("it handles unknown preset", func(t *testing.T) {
+		dir := newStoreDir(t)
+		newStoredBulb(t, dir, "pikachu")
+
+		_, err := execute(t, "preset", "apply", "reading", "pikachu")
+		require.ErrorIs(t, err, app.ErrPresetNotFound)
+		require.EqualError(t, err, `find "reading" preset: not found`)
+	})
+
+	t.Run("it completes preset names", func(t *testing.T) {
+		newStoreDir(t)
+
+		_, err := execute(t, "preset", "save", "reading", "--bright", "100")
+		require.NoError(t, err)
+
+		output, err := execute(t, "__complete", "preset", "apply", "")
+		require.NoError(t, err)
+		require.Contains(t, output, "reading\n")
+	})
+}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+type relativeValue struct {
+	delta   int
+	percent bool
+}
+
+func parseRelativeValue(value string) (relativeValue, bool, error) {
+	if !strings.HasPrefix(value, "+") && !strings.HasPrefix(value, "-") {
+		return relativeValue{}, false, nil
+	}
+
+	percent := strings.HasSuffix(value, "%")
+	delta, err := strconv.Atoi(strings.TrimSuffix(value, "%"))
+	if err != nil {
+		return relativeValue{}, true, err
+	}
+
+	return relativeValue{delta: delta, percent: percent}, true, nil
+}
+
+// relativeValuePattern matches values like -10 or -20% which would otherwise
+// be read as shorthand flags.
+var relativeValuePattern = regexp.MustCompile(`^[+-]\d+%?$`)
+
+// acceptRelativeValues lets cmd take a bulb name and a value like -10 without
+// -- before it. Flag parsing stops at the bulb name, so the value is not read
+// as a shorthand flag, and the flags around the value are parsed with the args.
+func acceptRelativeValues(cmd *cobra.Command) {
+	cmd.Flags().SetInterspersed(false)
+	cmd.Args = func(cmd *cobra.Command, args []string) error {
+		values, flags := relativeArgs(cmd.Flags(), args)
+		if err := cmd.Flags().Parse(flags); err != nil {
+			return cmd.FlagErrorFunc()(cmd, err)
+		}
+
+		if help, _ := cmd.Flags().GetBool("help"); help {
+			return pflag.ErrHelp
+		}
+
+		return cobra.ExactArgs(2)(cmd, values)
+	}
+}
+
+// relativeArgs splits args into the values, which are the tokens not starting
+// with - and relative values, and the flags with their values. The arg after
+// -- is always a value.
+func relativeArgs(flags *pflag.FlagSet, args []string) ([]string, []string) {
+	values := make([]string, 0, 2)
+	rest := make([]string, 0, len(args))
+	for len(args) > 0 {
+		arg := args[0]
+		args = args[1:]
+
+		switch {
+		case arg == "--" && len(args) > 0:
+			values = append(values, args[0])
+			args = args[1:]
+		case !strings.HasPrefix(arg, "-") || relativeValuePattern.MatchString(arg):
+			values = append(values, arg)
+		default:
+			rest = append(rest, arg)
+			if takesValue(flags, arg) && len(args) > 0 {
+				rest = append(rest, args[0])
+				args = args[1:]
+			}
+		}
+	}
+
+	return values, rest
+}
+
+// takesValue tells whether the flag arg is followed by its value as the next
+// arg.
+func takesValue(flags *pflag.FlagSet, arg string) bool {
+	needsValue := func(flag *pflag.Flag) bool {
+		return flag != nil && flag.NoOptDefVal == ""
+	}
+
+	if name, ok := strings.CutPrefix(arg, "--"); ok {
+		return !strings.Contains(name, "=") && needsValue(flags.Lookup(name))
+	}
+
+	shorthands := strings.TrimPrefix(arg, "-")
+	for i, shorthand := range shorthands {
+		if needsValue(flags.ShorthandLookup(string(shorthand))) {
+			return i == len(shorthands)-1
+		}
+	}
+
+	return false
+}
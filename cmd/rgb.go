@@ -2,17 +2,18 @@ package cmd
 
 import (
 	"fmt"
-	"strconv"
 
 	"github.com/pugkong/ylc/app"
+	"github.com/pugkong/ylc/color"
 	"github.com/pugkong/ylc/yeelight"
 	"github.com/spf13/cobra"
 )
 
 var (
-	rgbBackground *bool
-	rgbEffect     = &yeelight.EffectSmooth
-	rgbDuration   *int
+	rgbLights   = app.LightsMain
+	rgbEffect   = yeelight.EffectSmooth
+	rgbDuration *int
+	rgbOn       bool
 )
 
 var rgbCmd = &cobra.Command{
@@ -25,29 +26,30 @@ var rgbCmd = &cobra.Command{
 			return store.AllNames(), cobra.ShellCompDirectiveDefault
 		}
 
+		if len(args) == 1 {
+			return color.Names(), cobra.ShellCompDirectiveDefault
+		}
+
 		return nil, cobra.ShellCompDirectiveDefault
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		name := args[0]
-		value, err := strconv.ParseInt(args[1], 16, 32)
+		value, err := color.Parse(args[1])
 		if err != nil {
 			return fmt.Errorf("parse color: %w", err)
 		}
 
-		control := app.NewControl(store, cmd)
-
-		if *rgbBackground {
-			return control.SetBackgroundRGB(name, int(value), *rgbEffect, *rgbDuration)
-		}
+		control := app.NewControl(store, dialer, cmd)
 
-		return control.SetRGB(name, int(value), *rgbEffect, *rgbDuration)
+		return control.SetRGB(name, rgbLights, value, powerOnEnabled(cmd, rgbOn), rgbEffect, *rgbDuration)
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(rgbCmd)
 
-	rgbBackground = rgbCmd.Flags().Bool("bg", false, "set background color")
-	rgbCmd.Flags().VarP(newEffectValue(rgbEffect), "effect", "e", "smooth or sudden")
+	addLightsFlags(rgbCmd, &rgbLights)
+	addOnFlag(rgbCmd, &rgbOn)
+	rgbCmd.Flags().VarP(newEffectValue(&rgbEffect), "effect", "e", "smooth or sudden")
 	rgbDuration = rgbCmd.Flags().IntP("duration", "d", 500, "effect duration (ms)")
 }
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/pugkong/ylc/app"
+	"github.com/pugkong/ylc/color"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRGBCmd(t *testing.T) {
+	t.Run("it sets rgb color", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+
+		_, err := execute(t, "rgb", "pikachu", "ff0000")
+		require.NoError(t, err)
+		require.Equal(t, "16711680", bulb.Prop("rgb"))
+		require.Equal(t, "1", bulb.Prop("color_mode"))
+	})
+
+	t.Run("it sets background rgb color", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+		bulb.SetProp("bg_power", "on")
+
+		_, err := execute(t, "rgb", "pikachu", "00ff00", "--light", "bg")
+		require.NoError(t, err)
+		require.Equal(t, "65280", bulb.Prop("bg_rgb"))
+		require.Equal(t, "1", bulb.Prop("bg_lmode"))
+		require.Equal(t, "2", bulb.Prop("color_mode"))
+	})
+
+	t.Run("it accepts color notations", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+
+		for value, rgb := range map[string]string{
+			"tomato":            "16737095",
+			"#0f0":              "65280",
+			"rgb(0, 0, 255)":    "255",
+			"hsl(0, 100%, 50%)": "16711680",
+			"6500K":             "16776954",
+		} {
+			_, err := execute(t, "rgb", "pikachu", value)
+			require.NoError(t, err)
+			require.Equal(t, rgb, bulb.Prop("rgb"), value)
+		}
+	})
+
+	t.Run("it completes color names", func(t *testing.T) {
+		newStoreDir(t)
+
+		output, err := execute(t, "__complete", "rgb", "pikachu", "")
+		require.NoError(t, err)
+		require.Contains(t, output, "\ntomato\n")
+	})
+
+	t.Run("it handles invalid color", func(t *testing.T) {
+		newStoreDir(t)
+
+		_, err := execute(t, "rgb", "pikachu", "reddish")
+		require.ErrorIs(t, err, color.ErrUnknownColor)
+		require.EqualError(t, err, `parse color: unknown color: "reddish"`)
+	})
+
+	t.Run("it handles unknown bulb", func(t *testing.T) {
+		newStoreDir(t)
+
+		_, err := execute(t, "rgb", "pikachu", "ff0000")
+		require.ErrorIs(t, err, app.ErrBulbNotFound)
+	})
+	t.Run("it powers on bulb first", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+		bulb.SetProp("power", "off")
+
+		_, err := execute(t, "rgb", "pikachu", "red", "--on")
+		require.NoError(t, err)
+		require.Equal(t, []string{"get_prop", "set_power", "set_rgb"}, bulb.Methods())
+		require.Equal(t, "on", bulb.Prop("power"))
+		require.Equal(t, "16711680", bulb.Prop("rgb"))
+	})
+}
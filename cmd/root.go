@@ -1,11 +1,16 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
 	"path"
+	"time"
 
 	"github.com/pugkong/ylc/app"
+	"github.com/pugkong/ylc/yeelight"
 	"github.com/spf13/cobra"
 )
 
@@ -14,38 +19,337 @@ var (
 	controlGroup = cobra.Group{ID: "control", Title: "Bulb Control"}
 )
 
-var store *app.BulbFileStore
+var (
+	store     *app.BulbFileStore
+	snapshots *app.SnapshotFileStore
+	presets   *app.PresetFileStore
+	schedules *app.ScheduleFileStore
+	dialer    *app.Dialer
+	pool      *app.ConnPool
+	config    app.Config
+
+	// storesDir is the dir the stores were loaded from.
+	storesDir string
+
+	tracer   *app.Tracer
+	recorder *yeelight.Recorder
+)
+
+var (
+	rootVerbose  = new(bool)
+	rootLogLevel = new(string)
+	rootTrace    = new(string)
+	rootRecord   = new(string)
+	rootWait     = new(bool)
+	rootAttempts = new(int)
+	rootBackoff  = new(time.Duration)
+	rootTimeout  = new(time.Duration)
+	rootDryRun   = new(bool)
+	rootClamp    = new(bool)
+
+	traceClose  func() error
+	recordClose func() error
+
+	// dryRunOut and clampAll keep the dry run and clamp mode of the top
+	// command for nested ones.
+	dryRunOut  io.Writer
+	dryRunStop func() error
+	clampAll   bool
+)
+
+var ErrUnknownLogLevel = errors.New("unknown log level")
 
 var rootCmd = &cobra.Command{
 	Use:   "ylc",
 	Short: "A CLI tool to control your Yeelight bulbs",
-	PersistentPreRunE: func(*cobra.Command, []string) error {
-		cacheDir, err := os.UserCacheDir()
+	PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
+		if !nested {
+			if err := setupOutputs(cmd.ErrOrStderr()); err != nil {
+				return err
+			}
+
+			if err := setupDryRun(cmd.OutOrStdout()); err != nil {
+				return err
+			}
+
+			clampAll = *rootClamp
+		}
+
+		configDir, err := os.UserConfigDir()
 		if err != nil {
-			return fmt.Errorf("get user cache dir: %w", err)
+			return fmt.Errorf("get user config dir: %w", err)
+		}
+
+		config, err = app.LoadConfig(path.Join(configDir, "ylc"))
+		if err != nil {
+			return fmt.Errorf("load config: %w", err)
+		}
+
+		appCacheDir, err := cacheDir()
+		if err != nil {
+			return err
 		}
 
-		appCacheDir := path.Join(cacheDir, "ylc")
 		if err := os.MkdirAll(appCacheDir, 0o700); err != nil {
 			return fmt.Errorf("make cache dir: %w", err)
 		}
 
-		store = app.NewBulbFileStore(appCacheDir)
-		if err := store.Init(); err != nil {
-			return fmt.Errorf("init bulb store: %w", err)
+		// Commands of the shell share the stores it loaded, as they save
+		// every change they make.
+		if !nested || pool == nil || storesDir != appCacheDir {
+			if err := initStores(appCacheDir); err != nil {
+				return err
+			}
+		}
+
+		dialer = &app.Dialer{
+			Tracer:   tracer,
+			Recorder: recorder,
+			Limiter:  app.NewRateLimiter(appCacheDir, app.QuotaCommands, app.QuotaPeriod, *rootWait),
+			Retry:    retryPolicy(cmd),
+			Timeout:  time.Duration(config.Timeout),
+			Pool:     pool,
+			Clamp:    clampAll || *rootClamp,
+		}
+		switch {
+		case dryRunOut != nil:
+			dialer.DryRun = dryRunOut
+		case *rootDryRun:
+			dialer.DryRun = cmd.OutOrStdout()
+		case checkDir != "":
+			dialer.DryRun, dialer.Check = io.Discard, true
+		}
+		if cmd.Flags().Changed("timeout") {
+			dialer.Timeout = *rootTimeout
 		}
 
 		return nil
 	},
+	PersistentPostRunE: func(*cobra.Command, []string) error {
+		return cleanup()
+	},
 }
 
 func init() {
 	rootCmd.AddGroup(&manageGroup, &controlGroup)
+
+	rootCmd.PersistentFlags().BoolVarP(rootVerbose, "verbose", "v", false, "Log debug messages, same as --log-level debug")
+	rootCmd.PersistentFlags().StringVar(rootLogLevel, "log-level", "warn", "Log level: debug, info, warn or error")
+	rootCmd.PersistentFlags().StringVar(rootTrace, "trace", "", "Dump bulb protocol lines to a file, or to stderr if no file is given")
+	rootCmd.PersistentFlags().Lookup("trace").NoOptDefVal = "-"
+	rootCmd.PersistentFlags().StringVar(rootRecord, "record", "", "Record bulb sessions to a file for replay in tests")
+	rootCmd.PersistentFlags().BoolVar(rootWait, "wait", false, "Wait when the bulb command quota is used up instead of failing")
+	rootCmd.PersistentFlags().IntVar(rootAttempts, "attempts", app.DefaultRetryPolicy.Attempts,
+		"Attempts to connect and send a command on network failures")
+	rootCmd.PersistentFlags().DurationVar(rootBackoff, "retry-backoff", time.Duration(app.DefaultRetryPolicy.Backoff),
+		"Delay before the first retry, doubled for each next one")
+	rootCmd.PersistentFlags().DurationVar(rootTimeout, "timeout", app.DefaultTimeout,
+		"Timeout to connect to a bulb and to wait for its response")
+	rootCmd.PersistentFlags().BoolVar(rootDryRun, "dry-run", false,
+		"Print the commands for bulbs instead of connecting to them")
+	rootCmd.PersistentFlags().BoolVar(rootClamp, "clamp", false,
+		"Bring values out of range to the nearest allowed one instead of failing")
+}
+
+func initStores(dir string) error {
+	store = app.NewBulbFileStore(dir)
+	if err := store.Init(); err != nil {
+		return fmt.Errorf("init bulb store: %w", err)
+	}
+
+	snapshots = app.NewSnapshotFileStore(dir)
+	if err := snapshots.Init(); err != nil {
+		return fmt.Errorf("init snapshot store: %w", err)
+	}
+
+	presets = app.NewPresetFileStore(dir)
+	if err := presets.Init(); err != nil {
+		return fmt.Errorf("init preset store: %w", err)
+	}
+
+	schedules = app.NewScheduleFileStore(dir)
+	if err := schedules.Init(); err != nil {
+		return fmt.Errorf("init schedule store: %w", err)
+	}
+
+	storesDir = dir
+
+	return nil
+}
+
+// cacheDir returns the dir of the stores, or of their copy while checking a
+// script.
+func cacheDir() (string, error) {
+	if checkDir != "" {
+		return checkDir, nil
+	}
+
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("get user cache dir: %w", err)
+	}
+
+	return path.Join(dir, "ylc"), nil
+}
+
+func retryPolicy(cmd *cobra.Command) app.RetryPolicy {
+	policy := config.Retry
+	if cmd.Flags().Changed("attempts") {
+		policy.Attempts = *rootAttempts
+	}
+
+	if cmd.Flags().Changed("retry-backoff") {
+		policy.Backoff = app.Duration(*rootBackoff)
+	}
+
+	return policy
+}
+
+func setupOutputs(stderr io.Writer) error {
+	if err := setupLogging(stderr); err != nil {
+		return err
+	}
+
+	var err error
+	tracer, err = setupTrace(stderr)
+	if err != nil {
+		return err
+	}
+
+	recorder, err = setupRecord()
+
+	return err
+}
+
+// setupDryRun prints bulb commands to w instead of sending them and keeps
+// saved data unchanged by running against a copy of the stores.
+func setupDryRun(w io.Writer) error {
+	if err := stopDryRun(); err != nil {
+		return err
+	}
+
+	dryRunOut = nil
+	if !*rootDryRun {
+		return nil
+	}
+
+	stop, err := startCheck()
+	if err != nil {
+		return err
+	}
+
+	dryRunOut, dryRunStop = w, stop
+
+	return nil
+}
+
+func stopDryRun() error {
+	if dryRunStop == nil {
+		return nil
+	}
+
+	err := dryRunStop()
+	dryRunStop = nil
+	if err != nil {
+		return fmt.Errorf("remove dry run copy: %w", err)
+	}
+
+	return nil
+}
+
+func setupLogging(w io.Writer) error {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(*rootLogLevel)); err != nil {
+		return fmt.Errorf("%w: %q", ErrUnknownLogLevel, *rootLogLevel)
+	}
+
+	if *rootVerbose {
+		level = slog.LevelDebug
+	}
+
+	slog.SetDefault(slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{Level: level})))
+
+	return nil
+}
+
+func setupTrace(stderr io.Writer) (*app.Tracer, error) {
+	if err := closeTrace(); err != nil {
+		return nil, err
+	}
+
+	switch *rootTrace {
+	case "":
+		return nil, nil
+	case "-":
+		return app.NewTracer(stderr), nil
+	}
+
+	file, err := os.OpenFile(*rootTrace, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("open trace file: %w", err)
+	}
+	traceClose = file.Close
+
+	return app.NewTracer(file), nil
+}
+
+func setupRecord() (*yeelight.Recorder, error) {
+	if err := closeRecord(); err != nil {
+		return nil, err
+	}
+
+	if *rootRecord == "" {
+		return nil, nil
+	}
+
+	file, err := os.OpenFile(*rootRecord, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("open record file: %w", err)
+	}
+	recordClose = file.Close
+
+	return yeelight.NewRecorder(file), nil
+}
+
+func closeRecord() error {
+	if recordClose == nil {
+		return nil
+	}
+
+	err := recordClose()
+	recordClose = nil
+	if err != nil {
+		return fmt.Errorf("close record file: %w", err)
+	}
+
+	return nil
+}
+
+func cleanup() error {
+	if nested {
+		return nil
+	}
+
+	return errors.Join(stopDryRun(), closeTrace(), closeRecord())
+}
+
+func closeTrace() error {
+	if traceClose == nil {
+		return nil
+	}
+
+	err := traceClose()
+	traceClose = nil
+	if err != nil {
+		return fmt.Errorf("close trace file: %w", err)
+	}
+
+	return nil
 }
 
 func Execute() {
 	err := rootCmd.Execute()
-	if err != nil {
+	if err := errors.Join(err, cleanup()); err != nil {
 		os.Exit(1)
 	}
 }
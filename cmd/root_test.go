@@ -0,0 +1,392 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pugkong/ylc/app"
+	"github.com/pugkong/ylc/yeelight"
+	"github.com/pugkong/ylc/yeelight/yeelighttest"
+	"github.com/stretchr/testify/require"
+)
+
+func newStoreDir(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_CACHE_HOME", dir)
+	t.Setenv("XDG_CONFIG_HOME", dir)
+
+	cacheDir, err := os.UserCacheDir()
+	require.NoError(t, err)
+
+	storeDir := path.Join(cacheDir, "ylc")
+	require.NoError(t, os.MkdirAll(storeDir, 0o700))
+
+	return storeDir
+}
+
+func saveBulbs(t *testing.T, dir string, bulbs ...app.Bulb) {
+	t.Helper()
+
+	bulbStore := app.NewBulbFileStore(dir)
+	require.NoError(t, bulbStore.Init())
+
+	for _, bulb := range bulbs {
+		bulbStore.Save(bulb)
+	}
+
+	require.NoError(t, bulbStore.Flush())
+}
+
+func loadBulbs(t *testing.T, dir string) []app.Bulb {
+	t.Helper()
+
+	bulbStore := app.NewBulbFileStore(dir)
+	require.NoError(t, bulbStore.Init())
+
+	return bulbStore.All()
+}
+
+func newBulb(t *testing.T, id string) *yeelighttest.Bulb {
+	t.Helper()
+
+	bulb, err := yeelighttest.NewBulb(id)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, bulb.Close()) })
+
+	return bulb
+}
+
+func newStoredBulb(t *testing.T, dir, name string) *yeelighttest.Bulb {
+	t.Helper()
+
+	bulb := newBulb(t, "0x"+name)
+	saveBulbs(t, dir, app.Bulb{ID: bulb.ID, Name: name, Addr: bulb.Addr()})
+
+	return bulb
+}
+
+func unreachableAddr(t *testing.T) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	addr := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	return addr
+}
+
+func execute(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+
+	var output bytes.Buffer
+
+	resetFlags(rootCmd)
+	rootCmd.SetOut(&output)
+	rootCmd.SetErr(&output)
+	rootCmd.SetArgs(args)
+	t.Cleanup(func() {
+		rootCmd.SetOut(nil)
+		rootCmd.SetErr(nil)
+		rootCmd.SetArgs(nil)
+	})
+
+	_, err := rootCmd.ExecuteC()
+
+	return output.String(), err
+}
+
+func TestRootCmd(t *testing.T) {
+	t.Run("it creates store dir", func(t *testing.T) {
+		dir := t.TempDir()
+		t.Setenv("HOME", dir)
+		t.Setenv("XDG_CACHE_HOME", dir)
+		t.Setenv("XDG_CONFIG_HOME", dir)
+
+		_, err := execute(t, "list")
+		require.NoError(t, err)
+
+		cacheDir, err := os.UserCacheDir()
+		require.NoError(t, err)
+		require.DirExists(t, path.Join(cacheDir, "ylc"))
+	})
+
+	t.Run("it handles broken store", func(t *testing.T) {
+		dir := newStoreDir(t)
+		require.NoError(t, os.WriteFile(path.Join(dir, "bulbs.json"), []byte("{"), 0o600))
+
+		_, err := execute(t, "list")
+		require.ErrorContains(t, err, "init bulb store: decode data from")
+	})
+	t.Run("it traces protocol lines to stderr", func(t *testing.T) {
+		dir := newStoreDir(t)
+		newStoredBulb(t, dir, "pikachu")
+
+		output, err := execute(t, "bright", "pikachu", "50", "--trace")
+		require.NoError(t, err)
+		require.Regexp(t, `pikachu request +\{"id":1,"method":"set_bright","params":\[50,"smooth",500\]\}`, output)
+		require.Regexp(t, `pikachu notify +\{"method":"props"`, output)
+		require.Regexp(t, `pikachu response +\{"id":1,"result":\["ok"\]\}`, output)
+	})
+
+	t.Run("it traces protocol lines to a file", func(t *testing.T) {
+		dir := newStoreDir(t)
+		newStoredBulb(t, dir, "pikachu")
+		tracePath := path.Join(dir, "ylc.trace")
+
+		output, err := execute(t, "bright", "pikachu", "50", "--trace="+tracePath)
+		require.NoError(t, err)
+		require.NotContains(t, output, "set_bright")
+
+		trace, err := os.ReadFile(tracePath)
+		require.NoError(t, err)
+		require.Contains(t, string(trace), `"method":"set_bright"`)
+	})
+
+	t.Run("it records bulb sessions", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+		recordPath := path.Join(dir, "session.jsonl")
+
+		_, err := execute(t, "bright", "pikachu", "50", "--record", recordPath)
+		require.NoError(t, err)
+
+		replay, err := yeelighttest.LoadReplay(recordPath)
+		require.NoError(t, err)
+
+		conn := replay.TCPConn(bulb.Addr())
+		_, err = conn.Write([]byte("{\"id\":1,\"method\":\"set_bright\",\"params\":[50,\"smooth\",500]}\r\n"))
+		require.NoError(t, err)
+	})
+
+	t.Run("it logs debug messages", func(t *testing.T) {
+		dir := newStoreDir(t)
+		newStoredBulb(t, dir, "pikachu")
+
+		output, err := execute(t, "bright", "pikachu", "50", "--verbose")
+		require.NoError(t, err)
+		require.Contains(t, output, "level=DEBUG msg=\"send command\" id=1 method=set_bright")
+	})
+
+	t.Run("it rejects unknown log level", func(t *testing.T) {
+		newStoreDir(t)
+
+		_, err := execute(t, "list", "--log-level", "loud")
+		require.ErrorIs(t, err, ErrUnknownLogLevel)
+	})
+
+	t.Run("it prints commands in a dry run", func(t *testing.T) {
+		dir := newStoreDir(t)
+		saveBulbs(t, dir, app.Bulb{ID: "0x01", Name: "pikachu", Addr: unreachableAddr(t)})
+
+		output, err := execute(t, "bright", "pikachu", "42", "--dry-run")
+		require.NoError(t, err)
+		require.Equal(t, `pikachu {"id":1,"method":"set_bright","params":[42,"smooth",500]}`+"\n", output)
+	})
+
+	t.Run("it fails commands needing bulb state in a dry run", func(t *testing.T) {
+		dir := newStoreDir(t)
+		saveBulbs(t, dir, app.Bulb{ID: "0x01", Name: "pikachu", Addr: unreachableAddr(t)})
+
+		output, err := execute(t, "temperature", "pikachu", "-100", "--dry-run")
+		require.ErrorIs(t, err, app.ErrDryRunState)
+		require.Contains(t, output, `pikachu {"id":1,"method":"get_prop","params":["power",`)
+		require.NotContains(t, output, "set_ct_abx")
+
+		_, err = execute(t, "info", "pikachu", "--dry-run")
+		require.ErrorIs(t, err, app.ErrDryRunState)
+	})
+
+	t.Run("it keeps saved data in a dry run", func(t *testing.T) {
+		dir := newStoreDir(t)
+		saveBulbs(t, dir, app.Bulb{ID: "0x01", Name: "pikachu", Addr: unreachableAddr(t)})
+
+		_, err := execute(t, "delete", "pikachu", "--dry-run")
+		require.NoError(t, err)
+		require.Len(t, loadBulbs(t, dir), 1)
+	})
+
+	t.Run("it prints commands of nested commands in a dry run", func(t *testing.T) {
+		dir := newStoreDir(t)
+		saveBulbs(t, dir, app.Bulb{ID: "0x01", Name: "pikachu", Addr: unreachableAddr(t)})
+		file := saveScript(t, "power pikachu\n")
+
+		output, err := execute(t, "run", file, "--dry-run")
+		require.NoError(t, err)
+		require.Equal(t, `pikachu {"id":1,"method":"dev_toggle","params":[]}`+"\n", output)
+	})
+}
+
+func writeQuota(t *testing.T, dir, bulbID string, tokens float64) {
+	t.Helper()
+
+	data := fmt.Sprintf(`{%q:{"tokens":%g,"updated":%q}}`, bulbID, tokens, time.Now().Format(time.RFC3339Nano))
+	require.NoError(t, os.WriteFile(path.Join(dir, "quota.json"), []byte(data), 0o600))
+}
+
+func TestQuota(t *testing.T) {
+	t.Run("it fails when bulb quota is used up", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+		writeQuota(t, dir, bulb.ID, 0.5)
+
+		_, err := execute(t, "bright", "pikachu", "50")
+		require.ErrorIs(t, err, yeelight.ErrQuotaExceeded)
+
+		var quotaErr *app.QuotaError
+		require.ErrorAs(t, err, &quotaErr)
+		require.Equal(t, "pikachu", quotaErr.Bulb)
+		require.Positive(t, quotaErr.RetryAfter)
+		require.Empty(t, bulb.Methods())
+	})
+
+	t.Run("it waits for bulb quota", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+		writeQuota(t, dir, bulb.ID, 0.9)
+
+		_, err := execute(t, "bright", "pikachu", "50", "--wait")
+		require.NoError(t, err)
+		require.Equal(t, "50", bulb.Prop("bright"))
+	})
+
+	t.Run("it spends quota across runs", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+		writeQuota(t, dir, bulb.ID, 1.5)
+
+		_, err := execute(t, "bright", "pikachu", "50")
+		require.NoError(t, err)
+
+		_, err = execute(t, "bright", "pikachu", "60")
+		require.ErrorIs(t, err, yeelight.ErrQuotaExceeded)
+	})
+
+	t.Run("it shares quota between limiters on the same dir", func(t *testing.T) {
+		dir := t.TempDir()
+		bulb := app.Bulb{ID: "0x01", Name: "pikachu"}
+		first := app.NewRateLimiter(dir, 10, time.Hour, false)
+		second := app.NewRateLimiter(dir, 10, time.Hour, false)
+
+		var wg sync.WaitGroup
+		errs := make(chan error, 20)
+		for i := range 20 {
+			limiter := first
+			if i%2 == 1 {
+				limiter = second
+			}
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs <- limiter.Take(bulb)
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		taken := 0
+		for err := range errs {
+			if err == nil {
+				taken++
+			} else {
+				require.ErrorIs(t, err, yeelight.ErrQuotaExceeded)
+			}
+		}
+		require.Equal(t, 10, taken)
+	})
+
+	t.Run("it maps bulb quota error", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+		bulb.SetError("set_bright", -1, "client quota exceeded")
+
+		_, err := execute(t, "bright", "pikachu", "50")
+		require.ErrorIs(t, err, yeelight.ErrBulbResponse)
+		require.ErrorIs(t, err, yeelight.ErrQuotaExceeded)
+	})
+}
+
+func TestRetry(t *testing.T) {
+	t.Run("it sends command again after dropped connection", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+		bulb.DropCommands(1)
+
+		_, err := execute(t, "bright", "pikachu", "42", "--retry-backoff", "1ms")
+		require.NoError(t, err)
+		require.Equal(t, "42", bulb.Prop("bright"))
+	})
+
+	t.Run("it does not send toggle again", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+		bulb.DropCommands(1)
+
+		_, err := execute(t, "power", "pikachu", "--retry-backoff", "1ms")
+		require.ErrorIs(t, err, io.EOF)
+		require.Equal(t, "on", bulb.Prop("power"))
+	})
+
+	t.Run("it sends command again after connection dropped mid-response", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+		bulb.CutResponses(1)
+
+		_, err := execute(t, "bright", "pikachu", "42", "--retry-backoff", "1ms")
+		require.NoError(t, err)
+		require.Equal(t, "42", bulb.Prop("bright"))
+		require.Equal(t, []string{"set_bright", "set_bright"}, bulb.Methods())
+	})
+
+	t.Run("it does not send toggle again after connection dropped mid-response", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+		bulb.CutResponses(1)
+
+		_, err := execute(t, "power", "pikachu", "--retry-backoff", "1ms")
+		require.ErrorIs(t, err, io.EOF)
+		require.Equal(t, []string{"dev_toggle"}, bulb.Methods())
+	})
+
+	t.Run("it retries connect", func(t *testing.T) {
+		dir := newStoreDir(t)
+		saveBulbs(t, dir, app.Bulb{ID: "0x01", Name: "pikachu", Addr: unreachableAddr(t)})
+
+		output, err := execute(t, "bright", "pikachu", "42", "--attempts", "3", "--retry-backoff", "1ms", "-v")
+		require.ErrorContains(t, err, `connect to "pikachu" bulb`)
+		require.Equal(t, 2, strings.Count(output, "msg=retry"))
+	})
+
+	t.Run("it reads retry policy from config", func(t *testing.T) {
+		dir := newStoreDir(t)
+		saveBulbs(t, dir, app.Bulb{ID: "0x01", Name: "pikachu", Addr: unreachableAddr(t)})
+		require.NoError(t, os.WriteFile(path.Join(dir, "config.json"), []byte(`{"retry":{"attempts":1}}`), 0o600))
+
+		output, err := execute(t, "bright", "pikachu", "42", "-v")
+		require.Error(t, err)
+		require.NotContains(t, output, "msg=retry")
+	})
+
+	t.Run("it does not retry bulb errors", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+		bulb.SetError("set_bright", -1, "method not supported")
+
+		output, err := execute(t, "bright", "pikachu", "42", "-v")
+		require.ErrorIs(t, err, yeelight.ErrMethodNotSupported)
+		require.NotContains(t, output, "msg=retry")
+	})
+}
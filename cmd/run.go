@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path"
+	"syscall"
+
+	"github.com/pugkong/ylc/app"
+	"github.com/spf13/cobra"
+)
+
+var runCheck *bool
+
+// checkDir holds a copy of the stores while a script is checked or in a dry
+// run, so the commands can not change the real ones.
+var checkDir string
+
+var ErrCommandNotScriptable = errors.New("command can not run from a script")
+
+var runCmd = &cobra.Command{
+	GroupID: manageGroup.ID,
+	Use:     "run [file]",
+	Short:   "Run a script of ylc commands with sleeps, loops, parallel blocks and variables",
+	Example: `  ylc run wake-up.ylc
+  ylc run --check scenes/*.ylc
+  ylc run - < wake-up.ylc`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		check := *runCheck
+		if len(args) > 1 && !check {
+			return cobra.ExactArgs(1)(cmd, args)
+		}
+
+		scripts := make([]*app.Script, 0, len(args))
+		for _, name := range args {
+			script, err := loadScript(cmd, name)
+			if err != nil {
+				return err
+			}
+
+			scripts = append(scripts, script)
+		}
+
+		if check {
+			stop, err := startCheck()
+			if err != nil {
+				return err
+			}
+			defer func() { _ = stop() }()
+		}
+
+		ctx, stop := signal.NotifyContext(cmd.Context(), syscall.SIGINT, syscall.SIGTERM)
+		defer stop()
+
+		run := func(args []string) error {
+			if err := validateNested(args, ErrCommandNotScriptable); err != nil {
+				return err
+			}
+
+			return executeNested(args)
+		}
+
+		for i, script := range scripts {
+			if err := script.Run(ctx, run, check, cmd); err != nil {
+				return fmt.Errorf("run %q script: %w", args[i], err)
+			}
+		}
+
+		return nil
+	},
+}
+
+func loadScript(cmd *cobra.Command, name string) (*app.Script, error) {
+	var r io.Reader = cmd.InOrStdin()
+	if name != "-" {
+		file, err := os.Open(name)
+		if err != nil {
+			return nil, fmt.Errorf("open script: %w", err)
+		}
+		defer file.Close()
+
+		r = file
+	}
+
+	script, err := app.ParseScript(r)
+	if err != nil {
+		return nil, fmt.Errorf("parse %q script: %w", name, err)
+	}
+
+	return script, nil
+}
+
+// startCheck makes the next commands run against a copy of the stores and
+// without bulbs, and returns a func to stop it.
+func startCheck() (func() error, error) {
+	dir, err := cacheDir()
+	if err != nil {
+		return nil, err
+	}
+
+	tmp, err := os.MkdirTemp("", "ylc-check")
+	if err != nil {
+		return nil, fmt.Errorf("make check dir: %w", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, errors.Join(fmt.Errorf("read cache dir: %w", err), os.RemoveAll(tmp))
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		data, err := os.ReadFile(path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, errors.Join(fmt.Errorf("copy %q: %w", entry.Name(), err), os.RemoveAll(tmp))
+		}
+
+		if err := os.WriteFile(path.Join(tmp, entry.Name()), data, 0o600); err != nil {
+			return nil, errors.Join(fmt.Errorf("copy %q: %w", entry.Name(), err), os.RemoveAll(tmp))
+		}
+	}
+
+	previous := checkDir
+	checkDir = tmp
+
+	return func() error {
+		checkDir = previous
+
+		return os.RemoveAll(tmp)
+	}, nil
+}
+
+func init() {
+	rootCmd.AddCommand(runCmd)
+
+	runCheck = runCmd.Flags().Bool("check", false,
+		"check bulb names and values without touching bulbs or saved data, more than one file can be given")
+}
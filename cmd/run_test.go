@@ -0,0 +1,201 @@
+package cmd
+
+import (
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/pugkong/ylc/app"
+	"github.com/stretchr/testify/require"
+)
+
+func saveScript(t *testing.T, script string) string {
+	t.Helper()
+
+	file := path.Join(t.TempDir(), "script.ylc")
+	require.NoError(t, os.WriteFile(file, []byte(script), 0o600))
+
+	return file
+}
+
+func TestRunCmd(t *testing.T) {
+	t.Run("it runs script", func(t *testing.T) {
+		dir := newStoreDir(t)
+		pikachu := newBulb(t, "0xpikachu")
+		raichu := newBulb(t, "0xraichu")
+		saveBulbs(t, dir,
+			app.Bulb{ID: pikachu.ID, Name: "pikachu", Addr: pikachu.Addr()},
+			app.Bulb{ID: raichu.ID, Name: "raichu", Addr: raichu.Addr()},
+		)
+
+		file := saveScript(t, `# wake up
+set level 30
+for name in pikachu raichu {
+  bright $name $level
+}
+repeat 2 {
+  power pikachu
+  sleep 1ms
+}
+temperature "${name}" 2700 # the last loop value
+`)
+
+		_, err := execute(t, "run", file)
+		require.NoError(t, err)
+		require.Equal(t, "30", pikachu.Prop("bright"))
+		require.Equal(t, "on", pikachu.Prop("power"))
+		require.Equal(t, []string{"set_bright", "dev_toggle", "dev_toggle"}, pikachu.Methods())
+		require.Equal(t, "30", raichu.Prop("bright"))
+		require.Equal(t, "2700", raichu.Prop("ct"))
+	})
+
+	t.Run("it loops over words of variable", func(t *testing.T) {
+		dir := newStoreDir(t)
+		pikachu := newBulb(t, "0xpikachu")
+		raichu := newBulb(t, "0xraichu")
+		saveBulbs(t, dir,
+			app.Bulb{ID: pikachu.ID, Name: "pikachu", Addr: pikachu.Addr()},
+			app.Bulb{ID: raichu.ID, Name: "raichu", Addr: raichu.Addr()},
+		)
+
+		file := saveScript(t, `set bulbs pikachu raichu
+for name in $bulbs {
+  bright $name 30
+}
+`)
+
+		_, err := execute(t, "run", file)
+		require.NoError(t, err)
+		require.Equal(t, "30", pikachu.Prop("bright"))
+		require.Equal(t, "30", raichu.Prop("bright"))
+
+		_, err = execute(t, "run", saveScript(t, "set bulbs pikachu raichu\nfor name in \"$bulbs\" {\n  bright $name 30\n}\n"))
+		require.ErrorIs(t, err, app.ErrBulbNotFound)
+		require.ErrorContains(t, err, `"pikachu raichu"`)
+	})
+
+	t.Run("it keeps variables in single quotes", func(t *testing.T) {
+		dir := newStoreDir(t)
+		newStoredBulb(t, dir, "pikachu")
+
+		_, err := execute(t, "run", saveScript(t, "set name pikachu\nbright '$name' 30\n"))
+		require.ErrorIs(t, err, app.ErrBulbNotFound)
+		require.ErrorContains(t, err, `"$name"`)
+	})
+
+	t.Run("it runs parallel blocks", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+
+		file := saveScript(t, `parallel {
+  do {
+    sleep 200ms
+    bright pikachu 20
+  }
+  do {
+    sleep 200ms
+    temperature pikachu 3000
+  }
+  rgb pikachu red
+}
+`)
+
+		start := time.Now()
+		_, err := execute(t, "run", file)
+		require.NoError(t, err)
+		require.Less(t, time.Since(start), 350*time.Millisecond)
+		require.Equal(t, "set_rgb", bulb.Methods()[0])
+		require.Equal(t, "20", bulb.Prop("bright"))
+		require.Equal(t, "3000", bulb.Prop("ct"))
+	})
+
+	t.Run("it stops on first failure", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+
+		file := saveScript(t, "bright pikachu 10\nbright raichu 20\nbright pikachu 30\n")
+
+		_, err := execute(t, "run", file)
+		require.ErrorIs(t, err, app.ErrBulbNotFound)
+		require.ErrorContains(t, err, "line 2:")
+		require.Equal(t, "10", bulb.Prop("bright"))
+	})
+
+	t.Run("it refuses interactive commands", func(t *testing.T) {
+		newStoreDir(t)
+
+		_, err := execute(t, "run", saveScript(t, "shell\n"))
+		require.ErrorIs(t, err, ErrCommandNotScriptable)
+	})
+
+	t.Run("it handles invalid script", func(t *testing.T) {
+		newStoreDir(t)
+
+		_, err := execute(t, "run", saveScript(t, "repeat 2 {\n  power pikachu\n"))
+		require.ErrorIs(t, err, app.ErrUnclosedBlock)
+		require.ErrorContains(t, err, "line 1:")
+
+		_, err = execute(t, "run", saveScript(t, "}\n"))
+		require.ErrorIs(t, err, app.ErrUnexpectedEnd)
+
+		_, err = execute(t, "run", saveScript(t, "while true {\n}\n"))
+		require.ErrorIs(t, err, app.ErrInvalidStatement)
+
+		_, err = execute(t, "run", saveScript(t, "bright $bulb 10\n"))
+		require.ErrorIs(t, err, app.ErrUndefinedVariable)
+	})
+}
+
+func TestRunCmd_check(t *testing.T) {
+	t.Run("it checks script without touching bulbs", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+
+		file := saveScript(t, `repeat 100 {
+  bright pikachu 10
+  temperature pikachu +500
+  sleep 1h
+}
+delete pikachu
+`)
+
+		output, err := execute(t, "run", "--check", file)
+		require.NoError(t, err)
+		require.Equal(t, "Checked 3 commands\n", output)
+		require.Empty(t, bulb.Methods())
+		require.Len(t, loadBulbs(t, dir), 1)
+	})
+
+	t.Run("it checks body of repeat 0", func(t *testing.T) {
+		dir := newStoreDir(t)
+		newStoredBulb(t, dir, "pikachu")
+
+		_, err := execute(t, "run", "--check", saveScript(t, "repeat 0 {\n  bright raichu 10\n}\n"))
+		require.ErrorIs(t, err, app.ErrBulbNotFound)
+	})
+
+	t.Run("it finds unknown bulbs and invalid values", func(t *testing.T) {
+		dir := newStoreDir(t)
+		newStoredBulb(t, dir, "pikachu")
+
+		_, err := execute(t, "run", "--check", saveScript(t, "bright pikachu 10\nbright raichu 10\n"))
+		require.ErrorIs(t, err, app.ErrBulbNotFound)
+		require.ErrorContains(t, err, "line 2:")
+
+		_, err = execute(t, "run", "--check", saveScript(t, "bright pikachu bright\n"))
+		require.ErrorContains(t, err, "parse bright")
+
+		_, err = execute(t, "run", "--check", saveScript(t, "sleep soon\n"))
+		require.ErrorIs(t, err, app.ErrInvalidStatement)
+	})
+
+	t.Run("it checks several scripts", func(t *testing.T) {
+		dir := newStoreDir(t)
+		newStoredBulb(t, dir, "pikachu")
+
+		output, err := execute(t, "run", "--check", saveScript(t, "power pikachu\n"), saveScript(t, "power pikachu\n"))
+		require.NoError(t, err)
+		require.Equal(t, "Checked 1 commands\nChecked 1 commands\n", output)
+	})
+}
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/pugkong/ylc/app"
+	"github.com/spf13/cobra"
+)
+
+var scheduleCmd = &cobra.Command{
+	GroupID: manageGroup.ID,
+	Use:     "schedule",
+	Aliases: []string{"sc"},
+	Short:   "Manage jobs run by the scheduler",
+}
+
+var (
+	ErrUnknownCommand        = errors.New("unknown command")
+	ErrCommandNotSchedulable = errors.New("command can not be scheduled")
+	ErrInvalidJobID          = errors.New("invalid job id")
+)
+
+var scheduleAddCmd = &cobra.Command{
+	Use:   "add [trigger] -- [command...]",
+	Short: "Add a job running a ylc command on a cron expression or a sun trigger like sunset-30m",
+	Example: `  ylc schedule add "0 7 * * 1-5" -- bright pikachu 80
+  ylc schedule add @daily -- preset apply night pikachu
+  ylc schedule add sunset-30m -- bright pikachu 60 --on`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		command := args[1:]
+		if err := validateSchedulable(command); err != nil {
+			return err
+		}
+
+		return app.NewSchedules(schedules, config.Location, cmd).Add(args[0], command)
+	},
+}
+
+func validateSchedulable(args []string) error {
+	return validateNested(args, ErrCommandNotSchedulable, scheduleCmd)
+}
+
+var scheduleListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List jobs with their next run",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		return app.NewSchedules(schedules, config.Location, cmd).List()
+	},
+}
+
+var scheduleRemoveCmd = &cobra.Command{
+	Use:     "remove [job id]",
+	Aliases: []string{"rm"},
+	Short:   "Remove job",
+	Args:    cobra.ExactArgs(1),
+	ValidArgsFunction: func(_ *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return schedules.AllIDs(), cobra.ShellCompDirectiveDefault
+		}
+
+		return nil, cobra.ShellCompDirectiveDefault
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("%w: %q", ErrInvalidJobID, args[0])
+		}
+
+		return app.NewSchedules(schedules, config.Location, cmd).Remove(id)
+	},
+}
+
+var schedulePreviewCount *int
+
+var schedulePreviewCmd = &cobra.Command{
+	Use:   "preview",
+	Short: "Show upcoming runs without running anything",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		return app.NewSchedules(schedules, config.Location, cmd).Preview(*schedulePreviewCount)
+	},
+}
+
+var (
+	schedulerGrace *time.Duration
+	schedulerOnce  *bool
+)
+
+var schedulerCmd = &cobra.Command{
+	GroupID: manageGroup.ID,
+	Use:     "scheduler",
+	Short:   "Run scheduled jobs until interrupted",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		grace, once := *schedulerGrace, *schedulerOnce
+		scheduler := app.NewScheduler(schedules, config.Location, executeNested, grace, cmd)
+
+		if once {
+			_, err := scheduler.RunDue()
+
+			return err
+		}
+
+		ctx, stop := signal.NotifyContext(cmd.Context(), syscall.SIGINT, syscall.SIGTERM)
+		defer stop()
+
+		return scheduler.Run(ctx)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(scheduleCmd, schedulerCmd)
+	scheduleCmd.AddCommand(scheduleAddCmd, scheduleListCmd, scheduleRemoveCmd, schedulePreviewCmd)
+
+	schedulePreviewCount = schedulePreviewCmd.Flags().IntP("count", "n", 10, "number of runs to show")
+
+	schedulerGrace = schedulerCmd.Flags().Duration("grace", 15*time.Minute,
+		"run a job missed while the scheduler was down if it is not older than this")
+	schedulerOnce = schedulerCmd.Flags().Bool("once", false, "run due jobs once and exit")
+}
@@ -0,0 +1,212 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pugkong/ylc/app"
+	"github.com/pugkong/ylc/cron"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+)
+
+func saveJobs(t *testing.T, dir string, jobs ...app.Job) {
+	t.Helper()
+
+	jobStore := app.NewScheduleFileStore(dir)
+	require.NoError(t, jobStore.Init())
+
+	for _, job := range jobs {
+		jobStore.Save(job)
+	}
+
+	require.NoError(t, jobStore.Flush())
+}
+
+func loadJobs(t *testing.T, dir string) []app.Job {
+	t.Helper()
+
+	jobStore := app.NewScheduleFileStore(dir)
+	require.NoError(t, jobStore.Init())
+
+	return jobStore.All()
+}
+
+func TestScheduleCmd(t *testing.T) {
+	t.Run("it adds and lists jobs", func(t *testing.T) {
+		dir := newStoreDir(t)
+
+		output, err := execute(t, "schedule", "add", "0 7 * * 1-5", "--", "bright", "pikachu", "80", "--effect", "sudden")
+		require.NoError(t, err)
+		require.Contains(t, output, "Added job 1, next run at ")
+
+		jobs := loadJobs(t, dir)
+		require.Len(t, jobs, 1)
+		require.Equal(t, "0 7 * * 1-5", jobs[0].Trigger)
+		require.Equal(t, []string{"bright", "pikachu", "80", "--effect", "sudden"}, jobs[0].Args)
+
+		output, err = execute(t, "schedule", "list")
+		require.NoError(t, err)
+		require.Contains(t, output, "   1 0 7 * * 1-5")
+		require.Contains(t, output, "bright pikachu 80 --effect sudden\n")
+	})
+
+	t.Run("it rejects invalid trigger", func(t *testing.T) {
+		newStoreDir(t)
+
+		_, err := execute(t, "schedule", "add", "0 25 * * *", "--", "bright", "pikachu", "80")
+		require.ErrorIs(t, err, cron.ErrOutOfRange)
+	})
+
+	t.Run("it adds sun trigger", func(t *testing.T) {
+		dir := newStoreDir(t)
+
+		_, err := execute(t, "schedule", "add", "sunset-30m", "--", "bright", "pikachu", "60")
+		require.ErrorIs(t, err, app.ErrNoLocation)
+
+		saveConfig(t, dir, berlinConfig)
+
+		output, err := execute(t, "schedule", "add", "sunset-30m", "--", "bright", "pikachu", "60")
+		require.NoError(t, err)
+		require.Contains(t, output, "Added job 1, next run at ")
+		require.NotContains(t, output, "never")
+
+		_, err = execute(t, "schedule", "add", "sunrise30m", "--", "bright", "pikachu", "60")
+		require.ErrorIs(t, err, app.ErrInvalidTrigger)
+	})
+
+	t.Run("it rejects unknown and scheduler commands", func(t *testing.T) {
+		newStoreDir(t)
+
+		_, err := execute(t, "schedule", "add", "@daily", "--", "shine", "pikachu")
+		require.ErrorIs(t, err, ErrUnknownCommand)
+
+		_, err = execute(t, "schedule", "add", "@daily", "--", "scheduler")
+		require.ErrorIs(t, err, ErrCommandNotSchedulable)
+	})
+
+	t.Run("it removes job", func(t *testing.T) {
+		dir := newStoreDir(t)
+		saveJobs(t, dir, app.Job{ID: 1, Trigger: "@daily", Args: []string{"power", "pikachu"}})
+
+		_, err := execute(t, "schedule", "remove", "1")
+		require.NoError(t, err)
+		require.Empty(t, loadJobs(t, dir))
+
+		_, err = execute(t, "schedule", "remove", "1")
+		require.ErrorIs(t, err, app.ErrJobNotFound)
+	})
+
+	t.Run("it previews upcoming runs", func(t *testing.T) {
+		dir := newStoreDir(t)
+		saveJobs(t, dir,
+			app.Job{ID: 1, Trigger: "0 * * * *", Args: []string{"bright", "pikachu", "10"}},
+			app.Job{ID: 2, Trigger: "30 * * * *", Args: []string{"bright", "pikachu", "90"}},
+		)
+
+		output, err := execute(t, "schedule", "preview", "-n", "3")
+		require.NoError(t, err)
+
+		lines := strings.Split(strings.TrimSpace(output), "\n")
+		require.Len(t, lines, 3)
+		require.NotEqual(t, lines[0][strings.Index(lines[0], "job"):], lines[1][strings.Index(lines[1], "job"):])
+	})
+}
+
+func TestSchedulerCmd(t *testing.T) {
+	t.Run("it runs due job", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+		saveJobs(t, dir, app.Job{
+			ID:      1,
+			Trigger: "* * * * *",
+			Args:    []string{"bright", "pikachu", "42"},
+			Created: time.Now().Add(-time.Hour),
+		})
+
+		output, err := execute(t, "scheduler", "--once")
+		require.NoError(t, err)
+		require.Contains(t, output, "Run job 1: bright pikachu 42\n")
+		require.Equal(t, "42", bulb.Prop("bright"))
+		require.WithinDuration(t, time.Now(), loadJobs(t, dir)[0].LastRun, time.Minute)
+
+		output, err = execute(t, "scheduler", "--once")
+		require.NoError(t, err)
+		require.NotContains(t, output, "Run job")
+	})
+
+	t.Run("it skips run missed for longer than grace", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+		missed := time.Now().Add(-2 * time.Hour)
+		saveJobs(t, dir, app.Job{
+			ID:      1,
+			Trigger: fmt.Sprintf("%d %d * * *", missed.Minute(), missed.Hour()),
+			Args:    []string{"bright", "pikachu", "42"},
+			Created: time.Now().Add(-48 * time.Hour),
+		})
+
+		output, err := execute(t, "scheduler", "--once")
+		require.NoError(t, err)
+		require.Contains(t, output, "Skip missed run of job 1\n")
+		require.Equal(t, "100", bulb.Prop("bright"))
+
+		saveJobs(t, dir, app.Job{
+			ID:      1,
+			Trigger: fmt.Sprintf("%d %d * * *", missed.Minute(), missed.Hour()),
+			Args:    []string{"bright", "pikachu", "42"},
+			Created: time.Now().Add(-48 * time.Hour),
+		})
+
+		output, err = execute(t, "scheduler", "--once", "--grace", "3h")
+		require.NoError(t, err)
+		require.Contains(t, output, "Run job 1")
+		require.Equal(t, "42", bulb.Prop("bright"))
+	})
+
+	t.Run("it keeps jobs added while running due jobs", func(t *testing.T) {
+		dir := newStoreDir(t)
+		saveJobs(t, dir, app.Job{
+			ID:      1,
+			Trigger: "* * * * *",
+			Args:    []string{"power", "pikachu"},
+			Created: time.Now().Add(-time.Hour),
+		})
+
+		added := app.Job{ID: 2, Trigger: "@daily", Args: []string{"power", "pikachu"}, Created: time.Now()}
+		run := func([]string) error {
+			saveJobs(t, dir, added)
+
+			return nil
+		}
+
+		printer := &cobra.Command{}
+		printer.SetOut(io.Discard)
+
+		scheduler := app.NewScheduler(app.NewScheduleFileStore(dir), nil, run, time.Minute, printer)
+		_, err := scheduler.RunDue()
+		require.NoError(t, err)
+
+		jobs := loadJobs(t, dir)
+		require.Len(t, jobs, 2)
+		require.WithinDuration(t, time.Now(), jobs[0].LastRun, time.Minute)
+		require.True(t, jobs[1].LastRun.IsZero())
+	})
+
+	t.Run("it reports failed job and goes on", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+		saveJobs(t, dir,
+			app.Job{ID: 1, Trigger: "* * * * *", Args: []string{"bright", "ditto", "42"}, Created: time.Now().Add(-time.Hour)},
+			app.Job{ID: 2, Trigger: "* * * * *", Args: []string{"bright", "pikachu", "42"}, Created: time.Now().Add(-time.Hour)},
+		)
+
+		output, err := execute(t, "scheduler", "--once")
+		require.NoError(t, err)
+		require.Contains(t, output, `Job 1 failed: find "ditto" bulb: not found`)
+		require.Equal(t, "42", bulb.Prop("bright"))
+	})
+}
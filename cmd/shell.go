@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pugkong/ylc/app"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+const shellPrompt = "ylc> "
+
+var ErrNestedShell = errors.New("already in the shell")
+
+var shellCmd = &cobra.Command{
+	GroupID: manageGroup.ID,
+	Use:     "shell",
+	Short:   "Run commands interactively, keeping bulb connections open between them",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) (err error) {
+		if nested {
+			return ErrNestedShell
+		}
+
+		pool = app.NewConnPool()
+		defer func() {
+			if closeErr := pool.Close(); closeErr != nil {
+				err = errors.Join(err, fmt.Errorf("close bulb connections: %w", closeErr))
+			}
+			pool = nil
+		}()
+
+		if file, ok := cmd.InOrStdin().(*os.File); ok && term.IsTerminal(int(file.Fd())) {
+			return runTerminalShell(cmd, file)
+		}
+
+		scanner := bufio.NewScanner(cmd.InOrStdin())
+		for scanner.Scan() {
+			if runShellLine(cmd, scanner.Text()) {
+				return nil
+			}
+		}
+
+		if err := scanner.Err(); err != nil {
+			return fmt.Errorf("read input: %w", err)
+		}
+
+		return nil
+	},
+}
+
+func runTerminalShell(cmd *cobra.Command, file *os.File) error {
+	fd := int(file.Fd())
+	terminal := term.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{file, cmd.OutOrStdout()}, shellPrompt)
+
+	terminal.AutoCompleteCallback = func(line string, pos int, key rune) (string, int, bool) {
+		if key != '\t' {
+			return "", 0, false
+		}
+
+		newLine, newPos, matches := completeShellLine(line, pos)
+		if len(matches) > 1 && newLine == line {
+			_, _ = fmt.Fprintln(terminal, strings.Join(matches, "  "))
+		}
+
+		return newLine, newPos, true
+	}
+
+	for {
+		if width, height, err := term.GetSize(fd); err == nil && width > 0 {
+			_ = terminal.SetSize(width, height)
+		}
+
+		line, err := readTerminalLine(fd, terminal)
+		if errors.Is(err, io.EOF) {
+			cmd.Println()
+
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if runShellLine(cmd, line) {
+			return nil
+		}
+	}
+}
+
+// readTerminalLine puts the terminal in raw mode only while reading, so
+// commands print and handle signals as usual.
+func readTerminalLine(fd int, terminal *term.Terminal) (string, error) {
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return "", fmt.Errorf("make terminal raw: %w", err)
+	}
+	defer func() { _ = term.Restore(fd, state) }()
+
+	return terminal.ReadLine()
+}
+
+// runShellLine runs one command line and reports whether the shell should
+// exit. Errors are printed so the shell keeps going.
+func runShellLine(cmd *cobra.Command, line string) bool {
+	args, err := app.SplitWords(line)
+	if err != nil {
+		cmd.PrintErrln("Error:", err)
+
+		return false
+	}
+
+	if len(args) == 0 {
+		return false
+	}
+
+	switch args[0] {
+	case "exit", "quit":
+		return true
+	}
+
+	if err := executeNested(args); err != nil {
+		cmd.PrintErrln("Error:", err)
+	}
+
+	return false
+}
+
+// completeShellLine completes the word before the cursor and returns the
+// new line, the new cursor position and all matches.
+func completeShellLine(line string, pos int) (string, int, []string) {
+	head := line[:pos]
+	start := strings.LastIndexAny(head, " \t") + 1
+
+	args, err := app.SplitWords(head[:start])
+	if err != nil {
+		return line, pos, nil
+	}
+
+	partial := head[start:]
+	matches := shellCompletions(args, partial)
+
+	completion := partial
+	switch len(matches) {
+	case 0:
+		return line, pos, nil
+	case 1:
+		completion = matches[0] + " "
+	default:
+		completion = commonPrefix(matches)
+	}
+
+	return head[:start] + completion + line[pos:], start + len(completion), matches
+}
+
+// shellCompletions asks cobra for the completions of the partial word, the
+// same way shell completion scripts do.
+func shellCompletions(args []string, partial string) []string {
+	var output bytes.Buffer
+	out, errOut := rootCmd.OutOrStdout(), rootCmd.ErrOrStderr()
+	rootCmd.SetOut(&output)
+	rootCmd.SetErr(io.Discard)
+	defer func() {
+		rootCmd.SetOut(out)
+		rootCmd.SetErr(errOut)
+	}()
+
+	if err := executeNested(append(append([]string{cobra.ShellCompRequestCmd}, args...), partial)); err != nil {
+		return nil
+	}
+
+	var matches []string
+	for _, line := range strings.Split(output.String(), "\n") {
+		if strings.HasPrefix(line, ":") {
+			break
+		}
+
+		match, _, _ := strings.Cut(line, "\t")
+		if match != "" && strings.HasPrefix(match, partial) {
+			matches = append(matches, match)
+		}
+	}
+
+	return matches
+}
+
+func commonPrefix(values []string) string {
+	prefix := values[0]
+	for _, value := range values[1:] {
+		for !strings.HasPrefix(value, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+
+	return prefix
+}
+
+func init() {
+	rootCmd.AddCommand(shellCmd)
+}
@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"io"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pugkong/ylc/app"
+	"github.com/stretchr/testify/require"
+)
+
+func executeShell(t *testing.T, input string) (string, error) {
+	t.Helper()
+
+	rootCmd.SetIn(strings.NewReader(input))
+	t.Cleanup(func() { rootCmd.SetIn(nil) })
+
+	return execute(t, "shell")
+}
+
+// startShell runs the shell until the returned writer is closed, so the test
+// can act between lines. A write returns once the shell is done with the
+// lines written before.
+func startShell(t *testing.T) io.WriteCloser {
+	t.Helper()
+
+	input, writer := io.Pipe()
+	rootCmd.SetIn(input)
+
+	done := make(chan error)
+	go func() {
+		_, err := execute(t, "shell")
+		done <- err
+	}()
+
+	t.Cleanup(func() {
+		require.NoError(t, writer.Close())
+		require.NoError(t, <-done)
+		rootCmd.SetIn(nil)
+	})
+
+	return writer
+}
+
+func writeLines(t *testing.T, writer io.Writer, lines string) {
+	t.Helper()
+
+	_, err := io.WriteString(writer, lines)
+	require.NoError(t, err)
+
+	_, err = io.WriteString(writer, "\n")
+	require.NoError(t, err)
+}
+
+func TestShellCmd(t *testing.T) {
+	t.Run("it runs commands over one connection", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+
+		_, err := executeShell(t, "bright pikachu 50\n\ntemperature pikachu 3000 # warm\nexit\nbright pikachu 10\n")
+		require.NoError(t, err)
+		require.Equal(t, "50", bulb.Prop("bright"))
+		require.Equal(t, "3000", bulb.Prop("ct"))
+		require.Equal(t, 1, bulb.Accepted())
+	})
+
+	t.Run("it keeps going after errors", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+
+		output, err := executeShell(t, "bright pikachu bright\nbright 'pikachu\nbright pikachu 20\n")
+		require.NoError(t, err)
+		require.Contains(t, output, "Error: parse bright")
+		require.Contains(t, output, "Error: unterminated quote")
+		require.Equal(t, "20", bulb.Prop("bright"))
+	})
+
+	t.Run("it reconnects after bulb closed idle connection", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+
+		shell := startShell(t)
+		writeLines(t, shell, "power pikachu\n")
+		require.Equal(t, "off", bulb.Prop("power"))
+
+		require.NoError(t, bulb.CloseConnections())
+		require.Eventually(t, func() bool { return bulb.Connections() == 0 }, time.Second, 10*time.Millisecond)
+
+		writeLines(t, shell, "power pikachu\n")
+		require.Equal(t, "on", bulb.Prop("power"))
+		require.Equal(t, 2, bulb.Accepted())
+	})
+
+	t.Run("it loads stores once", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+
+		shell := startShell(t)
+		writeLines(t, shell, "bright pikachu 50\n")
+		require.NoError(t, os.WriteFile(path.Join(dir, "bulbs.json"), []byte("{}"), 0o600))
+
+		writeLines(t, shell, "bright pikachu 20\n")
+		require.Equal(t, "20", bulb.Prop("bright"))
+	})
+
+	t.Run("it refuses to nest", func(t *testing.T) {
+		newStoreDir(t)
+
+		output, err := executeShell(t, "shell\n")
+		require.NoError(t, err)
+		require.Contains(t, output, "Error: already in the shell")
+	})
+}
+
+func TestCompleteShellLine(t *testing.T) {
+	t.Run("it completes commands", func(t *testing.T) {
+		newStoreDir(t)
+
+		line, pos, _ := completeShellLine("brig", 4)
+		require.Equal(t, "bright ", line)
+		require.Equal(t, 7, pos)
+	})
+
+	t.Run("it completes bulb names", func(t *testing.T) {
+		dir := newStoreDir(t)
+		newStoredBulb(t, dir, "pikachu")
+
+		line, pos, _ := completeShellLine("bright pi 50", 9)
+		require.Equal(t, "bright pikachu  50", line)
+		require.Equal(t, 15, pos)
+	})
+
+	t.Run("it completes common prefix of values", func(t *testing.T) {
+		dir := newStoreDir(t)
+		newStoredBulb(t, dir, "pikachu")
+
+		line, _, matches := completeShellLine("timer pikachu 1", 15)
+		require.Equal(t, "timer pikachu 1", line)
+		require.Equal(t, []string{"15m", "1h"}, matches)
+	})
+}
+
+func TestSplitWords(t *testing.T) {
+	t.Run("it splits quoted words", func(t *testing.T) {
+		words, err := app.SplitWords(`schedule add "0 7 * * *" -- preset\ apply 'a b' "x\"y"`)
+		require.NoError(t, err)
+		require.Equal(t, []string{"schedule", "add", "0 7 * * *", "--", "preset apply", "a b", `x"y`}, words)
+	})
+
+	t.Run("it skips comments", func(t *testing.T) {
+		words, err := app.SplitWords("  # bright pikachu 10")
+		require.NoError(t, err)
+		require.Empty(t, words)
+	})
+
+	t.Run("it handles unterminated quote", func(t *testing.T) {
+		_, err := app.SplitWords(`bright "pikachu`)
+		require.ErrorIs(t, err, app.ErrUnterminatedQuote)
+	})
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"github.com/pugkong/ylc/app"
+	"github.com/pugkong/ylc/yeelight"
+	"github.com/spf13/cobra"
+)
+
+var snapshotCmd = &cobra.Command{
+	GroupID: controlGroup.ID,
+	Use:     "snapshot",
+	Aliases: []string{"snap"},
+	Short:   "Save and restore bulbs state",
+}
+
+var snapshotSaveCmd = &cobra.Command{
+	Use:   "save [snapshot name] [bulb name]...",
+	Short: "Save bulbs state, all known bulbs if none given",
+	Args:  cobra.MinimumNArgs(1),
+	ValidArgsFunction: func(_ *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return snapshots.AllNames(), cobra.ShellCompDirectiveDefault
+		}
+
+		return store.AllNames(), cobra.ShellCompDirectiveDefault
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return app.NewSnapshots(store, snapshots, dialer, cmd).Save(args[0], args[1:])
+	},
+}
+
+var (
+	snapshotRestoreEffect   = yeelight.EffectSmooth
+	snapshotRestoreDuration *int
+)
+
+var snapshotRestoreCmd = &cobra.Command{
+	Use:   "restore [snapshot name]",
+	Short: "Restore bulbs state",
+	Args:  cobra.ExactArgs(1),
+	ValidArgsFunction: func(_ *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return snapshots.AllNames(), cobra.ShellCompDirectiveDefault
+		}
+
+		return nil, cobra.ShellCompDirectiveDefault
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return app.NewSnapshots(store, snapshots, dialer, cmd).Restore(args[0], snapshotRestoreEffect, *snapshotRestoreDuration)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(snapshotCmd)
+	snapshotCmd.AddCommand(snapshotSaveCmd, snapshotRestoreCmd)
+
+	snapshotRestoreCmd.Flags().VarP(newEffectValue(&snapshotRestoreEffect), "effect", "e", "smooth or sudden")
+	snapshotRestoreDuration = snapshotRestoreCmd.Flags().IntP("duration", "d", 500, "effect duration (ms)")
+}
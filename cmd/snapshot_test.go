@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/pugkong/ylc/app"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotCmd(t *testing.T) {
+	t.Run("it saves and restores bulbs state", func(t *testing.T) {
+		dir := newStoreDir(t)
+		pikachu := newBulb(t, "0x01")
+		pikachu.SetProp("bright", "40")
+		pikachu.SetProp("color_mode", "1")
+		pikachu.SetProp("rgb", "16711680")
+		xatu := newBulb(t, "0x02")
+		xatu.SetProp("power", "off")
+		xatu.SetProp("bg_power", "on")
+		xatu.SetProp("bg_bright", "10")
+		xatu.SetProp("bg_lmode", "3")
+		xatu.SetProp("bg_hue", "120")
+		xatu.SetProp("bg_sat", "50")
+		saveBulbs(t, dir,
+			app.Bulb{ID: pikachu.ID, Name: "pikachu", Addr: pikachu.Addr()},
+			app.Bulb{ID: xatu.ID, Name: "xatu", Addr: xatu.Addr()},
+		)
+
+		_, err := execute(t, "snapshot", "save", "evening")
+		require.NoError(t, err)
+
+		_, err = execute(t, "bright", "pikachu", "100")
+		require.NoError(t, err)
+		_, err = execute(t, "temperature", "pikachu", "6500")
+		require.NoError(t, err)
+		_, err = execute(t, "power", "xatu")
+		require.NoError(t, err)
+		_, err = execute(t, "rgb", "xatu", "0000ff", "--bg")
+		require.NoError(t, err)
+
+		_, err = execute(t, "snapshot", "restore", "evening", "-e", "sudden")
+		require.NoError(t, err)
+
+		require.Equal(t, "on", pikachu.Prop("power"))
+		require.Equal(t, "40", pikachu.Prop("bright"))
+		require.Equal(t, "1", pikachu.Prop("color_mode"))
+		require.Equal(t, "16711680", pikachu.Prop("rgb"))
+
+		require.Equal(t, "off", xatu.Prop("power"))
+		require.Equal(t, "on", xatu.Prop("bg_power"))
+		require.Equal(t, "10", xatu.Prop("bg_bright"))
+		require.Equal(t, "3", xatu.Prop("bg_lmode"))
+		require.Equal(t, "120", xatu.Prop("bg_hue"))
+		require.Equal(t, "50", xatu.Prop("bg_sat"))
+	})
+
+	t.Run("it restores night light", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredCeilingBulb(t, dir, "pikachu")
+		bulb.SetProp("active_mode", "1")
+		bulb.SetProp("nl_br", "10")
+
+		_, err := execute(t, "snapshot", "save", "night")
+		require.NoError(t, err)
+
+		_, err = execute(t, "nightlight", "pikachu", "off")
+		require.NoError(t, err)
+		require.Equal(t, "0", bulb.Prop("active_mode"))
+
+		_, err = execute(t, "snapshot", "restore", "night")
+		require.NoError(t, err)
+		require.Equal(t, "on", bulb.Prop("power"))
+		require.Equal(t, "1", bulb.Prop("active_mode"))
+		require.Equal(t, "10", bulb.Prop("nl_br"))
+	})
+
+	t.Run("it restores daylight from night light", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredCeilingBulb(t, dir, "pikachu")
+		bulb.SetProp("bright", "60")
+
+		_, err := execute(t, "snapshot", "save", "day")
+		require.NoError(t, err)
+
+		_, err = execute(t, "nightlight", "pikachu", "on")
+		require.NoError(t, err)
+		require.Equal(t, "1", bulb.Prop("active_mode"))
+
+		_, err = execute(t, "snapshot", "restore", "day")
+		require.NoError(t, err)
+		require.Equal(t, "0", bulb.Prop("active_mode"))
+		require.Equal(t, "60", bulb.Prop("bright"))
+		require.Equal(t, "4000", bulb.Prop("ct"))
+	})
+
+	t.Run("it saves only given bulbs", func(t *testing.T) {
+		dir := newStoreDir(t)
+		pikachu := newBulb(t, "0x01")
+		saveBulbs(t, dir,
+			app.Bulb{ID: pikachu.ID, Name: "pikachu", Addr: pikachu.Addr()},
+			app.Bulb{ID: "0x02", Name: "xatu", Addr: unreachableAddr(t)},
+		)
+
+		_, err := execute(t, "snap", "save", "evening", "pikachu")
+		require.NoError(t, err)
+
+		data, err := os.ReadFile(path.Join(dir, "snapshots.json"))
+		require.NoError(t, err)
+
+		var snapshots map[string]app.Snapshot
+		require.NoError(t, json.Unmarshal(data, &snapshots))
+		require.Equal(t, map[string]app.Snapshot{
+			"evening": {
+				Name: "evening",
+				Bulbs: map[string]app.BulbState{
+					"0x01": {Main: app.LightState{
+						Power:            "on",
+						Bright:           100,
+						ColorMode:        app.ColorModeTemperature,
+						ColorTemperature: 4000,
+					}},
+				},
+			},
+		}, snapshots)
+	})
+
+	t.Run("it handles unknown bulb", func(t *testing.T) {
+		newStoreDir(t)
+
+		_, err := execute(t, "snapshot", "save", "evening", "pikachu")
+		require.ErrorIs(t, err, app.ErrBulbNotFound)
+	})
+
+	t.Run("it handles unknown snapshot", func(t *testing.T) {
+		newStoreDir(t)
+
+		_, err := execute(t, "snapshot", "restore", "evening")
+		require.ErrorIs(t, err, app.ErrSnapshotNotFound)
+		require.EqualError(t, err, `find "evening" snapshot: not found`)
+	})
+}
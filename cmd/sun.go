@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pugkong/ylc/app"
+	"github.com/spf13/cobra"
+)
+
+var sunDate *string
+
+var sunCmd = &cobra.Command{
+	GroupID: manageGroup.ID,
+	Use:     "sun",
+	Short:   "Show sunrise and sunset times at the configured location",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		var date time.Time
+		if *sunDate != "" {
+			var err error
+			date, err = time.Parse(time.DateOnly, *sunDate)
+			if err != nil {
+				return fmt.Errorf("parse date: %w", err)
+			}
+		}
+
+		return app.NewSun(config.Location, cmd).Show(date)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(sunCmd)
+
+	sunDate = sunCmd.Flags().String("date", "", "date as YYYY-MM-DD instead of today")
+}
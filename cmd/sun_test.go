@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/pugkong/ylc/app"
+	"github.com/stretchr/testify/require"
+)
+
+func saveConfig(t *testing.T, dir, config string) {
+	t.Helper()
+
+	require.NoError(t, os.WriteFile(path.Join(dir, "config.json"), []byte(config), 0o600))
+}
+
+const berlinConfig = `{"location":{"latitude":52.52,"longitude":13.405,"timezone":"UTC"}}`
+
+func TestSunCmd(t *testing.T) {
+	t.Run("it shows sun times", func(t *testing.T) {
+		dir := newStoreDir(t)
+		saveConfig(t, dir, berlinConfig)
+
+		output, err := execute(t, "sun", "--date", "2024-06-21")
+		require.NoError(t, err)
+		require.Equal(t, "Sunrise: 02:43\nSunset: 19:33\nDay length: 16h50m0s\n", output)
+	})
+
+	t.Run("it shows polar day", func(t *testing.T) {
+		dir := newStoreDir(t)
+		saveConfig(t, dir, `{"location":{"latitude":69.6492,"longitude":18.9553,"timezone":"UTC"}}`)
+
+		output, err := execute(t, "sun", "--date", "2024-06-21")
+		require.NoError(t, err)
+		require.Equal(t, "The sun does not set today\n", output)
+	})
+
+	t.Run("it needs location", func(t *testing.T) {
+		newStoreDir(t)
+
+		_, err := execute(t, "sun")
+		require.ErrorIs(t, err, app.ErrNoLocation)
+	})
+}
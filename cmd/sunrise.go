@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/pugkong/ylc/app"
+	"github.com/spf13/cobra"
+)
+
+var (
+	sunriseLights = app.LightsMain
+	sunriseOver   *time.Duration
+
+	sunsetLights = app.LightsMain
+	sunsetOver   *time.Duration
+	sunsetStay   *bool
+)
+
+var sunriseCmd = &cobra.Command{
+	GroupID: controlGroup.ID,
+	Use:     "sunrise [bulb name]",
+	Short:   "Wake up with a light brightening from deep red to daylight, run by the bulb itself",
+	Args:    cobra.ExactArgs(1),
+	ValidArgsFunction: func(_ *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return store.AllNames(), cobra.ShellCompDirectiveDefault
+		}
+
+		return nil, cobra.ShellCompDirectiveDefault
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return app.NewControl(store, dialer, cmd).Sunrise(args[0], sunriseLights, *sunriseOver)
+	},
+}
+
+var sunsetCmd = &cobra.Command{
+	GroupID: controlGroup.ID,
+	Use:     "sunset [bulb name]",
+	Short:   "Wind down with a light dimming to deep red and turning off, run by the bulb itself",
+	Args:    cobra.ExactArgs(1),
+	ValidArgsFunction: func(_ *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return store.AllNames(), cobra.ShellCompDirectiveDefault
+		}
+
+		return nil, cobra.ShellCompDirectiveDefault
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return app.NewControl(store, dialer, cmd).Sunset(args[0], sunsetLights, *sunsetOver, !*sunsetStay)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(sunriseCmd, sunsetCmd)
+
+	addLightsFlags(sunriseCmd, &sunriseLights)
+	sunriseOver = sunriseCmd.Flags().Duration("over", 30*time.Minute, "duration of the sunrise")
+
+	addLightsFlags(sunsetCmd, &sunsetLights)
+	sunsetOver = sunsetCmd.Flags().Duration("over", 30*time.Minute, "duration of the sunset")
+	sunsetStay = sunsetCmd.Flags().Bool("stay", false, "stay on at deep red instead of turning off")
+}
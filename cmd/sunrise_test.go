@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/pugkong/ylc/app"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSunriseCmd(t *testing.T) {
+	t.Run("it starts sunrise flow", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+		bulb.SetProp("power", "off")
+
+		_, err := execute(t, "sunrise", "pikachu")
+		require.NoError(t, err)
+		require.Equal(t, []string{"set_scene"}, bulb.Methods())
+		require.Equal(t, "on", bulb.Prop("power"))
+		require.Equal(t, "1", bulb.Prop("flowing"))
+		require.Equal(t, "6,1,"+
+			"50,1,16718336,1,"+
+			"360000,1,16729344,5,"+
+			"450000,1,16751135,20,"+
+			"450000,2,2700,50,"+
+			"270000,2,4000,80,"+
+			"270000,2,5500,100", bulb.Prop("flow_params"))
+	})
+
+	t.Run("it starts sunrise flow on background light", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+		bulb.SetProp("bg_power", "off")
+		bulb.SetProp("bg_bright", "100")
+		bulb.SetProp("bg_lmode", "2")
+
+		_, err := execute(t, "sunrise", "pikachu", "--light", "bg", "--over", "10m")
+		require.NoError(t, err)
+		require.Equal(t, []string{"get_prop", "bg_set_scene"}, bulb.Methods())
+		require.Equal(t, "on", bulb.Prop("bg_power"))
+		require.Equal(t, "1", bulb.Prop("bg_flowing"))
+		require.Empty(t, bulb.Prop("flowing"))
+	})
+
+	t.Run("it handles too short sunrise", func(t *testing.T) {
+		dir := newStoreDir(t)
+		newStoredBulb(t, dir, "pikachu")
+
+		_, err := execute(t, "sunrise", "pikachu", "--over", "10s")
+		require.ErrorIs(t, err, app.ErrFlowTooShort)
+	})
+
+	t.Run("it handles unknown bulb", func(t *testing.T) {
+		newStoreDir(t)
+
+		_, err := execute(t, "sunrise", "pikachu")
+		require.ErrorIs(t, err, app.ErrBulbNotFound)
+	})
+}
+
+func TestSunsetCmd(t *testing.T) {
+	t.Run("it starts sunset flow turning off at the end", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+
+		_, err := execute(t, "sunset", "pikachu", "--over", "1h")
+		require.NoError(t, err)
+		require.Equal(t, []string{"start_cf"}, bulb.Methods())
+		require.Equal(t, "4,2,"+
+			"1080000,2,2700,50,"+
+			"1080000,1,16751135,20,"+
+			"720000,1,16729344,5,"+
+			"720000,1,16718336,1", bulb.Prop("flow_params"))
+	})
+
+	t.Run("it stays on after sunset", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+
+		_, err := execute(t, "sunset", "pikachu", "--stay")
+		require.NoError(t, err)
+		require.Regexp(t, `^4,1,`, bulb.Prop("flow_params"))
+	})
+}
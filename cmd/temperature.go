@@ -10,17 +10,17 @@ import (
 )
 
 var (
-	temperatureBackground *bool
-	temperatureEffect     = &yeelight.EffectSmooth
-	temperatureDuration   *int
+	temperatureLights   = app.LightsMain
+	temperatureEffect   = yeelight.EffectSmooth
+	temperatureDuration *int
+	temperatureOn       bool
 )
 
 var temperatureCmd = &cobra.Command{
 	GroupID: controlGroup.ID,
-	Use:     "temperature [bulb name] [temperature]",
+	Use:     "temperature [bulb name] [temperature|+delta[%]|-delta[%]]",
 	Aliases: []string{"t", "temp"},
 	Short:   "Set color temperature",
-	Args:    cobra.ExactArgs(2),
 	ValidArgsFunction: func(_ *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
 		if len(args) == 0 {
 			return store.AllNames(), cobra.ShellCompDirectiveDefault
@@ -31,6 +31,7 @@ var temperatureCmd = &cobra.Command{
 			for i := 1700; i <= 6500; i += 100 {
 				temperatures = append(temperatures, strconv.Itoa(i))
 			}
+			temperatures = append(temperatures, "+500", "-500", "+10%", "-10%")
 
 			return temperatures, cobra.ShellCompDirectiveDefault
 		}
@@ -38,26 +39,56 @@ var temperatureCmd = &cobra.Command{
 		return nil, cobra.ShellCompDirectiveDefault
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
+		args, _ = relativeArgs(cmd.Flags(), args)
 		name := args[0]
-		value, err := strconv.Atoi(args[1])
+		control := app.NewControl(store, dialer, cmd)
+
+		relative, ok, err := parseRelativeValue(args[1])
 		if err != nil {
 			return fmt.Errorf("parse temperature: %w", err)
 		}
 
-		control := app.NewControl(store, cmd)
+		if ok {
+			return adjustTemperature(control, name, relative, powerOnEnabled(cmd, temperatureOn))
+		}
 
-		if *temperatureBackground {
-			return control.SetBackgroundTemperature(name, value, *temperatureEffect, *temperatureDuration)
+		value, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("parse temperature: %w", err)
 		}
 
-		return control.SetTemperature(name, value, *temperatureEffect, *temperatureDuration)
+		return control.SetTemperature(
+			name,
+			temperatureLights,
+			value,
+			powerOnEnabled(cmd, temperatureOn),
+			temperatureEffect,
+			*temperatureDuration,
+		)
 	},
 }
 
+func adjustTemperature(control *app.Control, name string, relative relativeValue, on bool) error {
+	if relative.percent {
+		return control.AdjustTemperature(name, temperatureLights, relative.delta, on, *temperatureDuration)
+	}
+
+	return control.ShiftTemperature(
+		name,
+		temperatureLights,
+		relative.delta,
+		on,
+		temperatureEffect,
+		*temperatureDuration,
+	)
+}
+
 func init() {
 	rootCmd.AddCommand(temperatureCmd)
+	acceptRelativeValues(temperatureCmd)
 
-	temperatureBackground = temperatureCmd.Flags().Bool("bg", false, "set background temperature")
-	temperatureCmd.Flags().VarP(newEffectValue(temperatureEffect), "effect", "e", "smooth or sudden")
+	addLightsFlags(temperatureCmd, &temperatureLights)
+	addOnFlag(temperatureCmd, &temperatureOn)
+	temperatureCmd.Flags().VarP(newEffectValue(&temperatureEffect), "effect", "e", "smooth or sudden")
 	temperatureDuration = temperatureCmd.Flags().IntP("duration", "d", 500, "effect duration (ms)")
 }
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/pugkong/ylc/app"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemperatureCmd(t *testing.T) {
+	t.Run("it sets color temperature", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+		bulb.SetProp("color_mode", "1")
+
+		_, err := execute(t, "temperature", "pikachu", "2700")
+		require.NoError(t, err)
+		require.Equal(t, "2700", bulb.Prop("ct"))
+		require.Equal(t, "2", bulb.Prop("color_mode"))
+	})
+
+	t.Run("it sets background color temperature", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+		bulb.SetProp("bg_power", "on")
+
+		_, err := execute(t, "temp", "pikachu", "6500", "--light", "bg")
+		require.NoError(t, err)
+		require.Equal(t, "6500", bulb.Prop("bg_ct"))
+		require.Equal(t, "4000", bulb.Prop("ct"))
+	})
+
+	t.Run("it sets temperature of both lights", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+		bulb.SetProp("bg_power", "on")
+
+		_, err := execute(t, "temperature", "pikachu", "3000", "--light", "both")
+		require.NoError(t, err)
+		require.Equal(t, "3000", bulb.Prop("ct"))
+		require.Equal(t, "3000", bulb.Prop("bg_ct"))
+	})
+
+	t.Run("it handles invalid light", func(t *testing.T) {
+		newStoreDir(t)
+
+		_, err := execute(t, "temperature", "pikachu", "3000", "--light", "side")
+		require.ErrorContains(t, err, `unknown light: "side"`)
+	})
+
+	t.Run("it handles invalid temperature", func(t *testing.T) {
+		newStoreDir(t)
+
+		_, err := execute(t, "temperature", "pikachu", "warm")
+		require.ErrorContains(t, err, "parse temperature")
+	})
+
+	t.Run("it handles unknown bulb", func(t *testing.T) {
+		newStoreDir(t)
+
+		_, err := execute(t, "temperature", "pikachu", "2700")
+		require.ErrorIs(t, err, app.ErrBulbNotFound)
+	})
+
+	t.Run("it handles unreachable bulb", func(t *testing.T) {
+		dir := newStoreDir(t)
+		saveBulbs(t, dir, app.Bulb{ID: "0x01", Name: "pikachu", Addr: unreachableAddr(t)})
+
+		_, err := execute(t, "temperature", "pikachu", "2700")
+		require.ErrorContains(t, err, `connect to "pikachu" bulb`)
+	})
+}
+
+func TestTemperatureCmd_relative(t *testing.T) {
+	t.Run("it shifts temperature by kelvins", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+
+		_, err := execute(t, "temperature", "pikachu", "+500")
+		require.NoError(t, err)
+		require.Equal(t, "4500", bulb.Prop("ct"))
+
+		_, err = execute(t, "temperature", "pikachu", "--", "-5000")
+		require.NoError(t, err)
+		require.Equal(t, "1700", bulb.Prop("ct"))
+	})
+
+	t.Run("it adjusts temperature by percentage", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+
+		_, err := execute(t, "temperature", "pikachu", "+10%")
+		require.NoError(t, err)
+		require.Equal(t, "4480", bulb.Prop("ct"))
+		require.Equal(t, []string{"adjust_ct"}, bulb.Methods())
+	})
+
+	t.Run("it takes negative value without separator", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+
+		_, err := execute(t, "temperature", "pikachu", "-500", "--effect", "sudden")
+		require.NoError(t, err)
+		require.Equal(t, "3500", bulb.Prop("ct"))
+
+		_, err = execute(t, "temperature", "pikachu", "-10%")
+		require.NoError(t, err)
+		require.Equal(t, "3020", bulb.Prop("ct"))
+	})
+
+	t.Run("it shifts background temperature", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+		bulb.SetProp("bg_power", "on")
+		bulb.SetProp("bg_bright", "100")
+		bulb.SetProp("bg_lmode", "2")
+		bulb.SetProp("bg_ct", "6000")
+
+		_, err := execute(t, "temperature", "pikachu", "+1000", "--light", "bg")
+		require.NoError(t, err)
+		require.Equal(t, "6500", bulb.Prop("bg_ct"))
+	})
+	t.Run("it powers on bulb before shift", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+		bulb.SetProp("power", "off")
+
+		_, err := execute(t, "temperature", "pikachu", "+500", "--on")
+		require.NoError(t, err)
+		require.Equal(t, []string{"get_prop", "set_power", "set_ct_abx"}, bulb.Methods())
+		require.Equal(t, "4500", bulb.Prop("ct"))
+	})
+}
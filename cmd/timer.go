@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/pugkong/ylc/app"
+	"github.com/spf13/cobra"
+)
+
+var (
+	timerCancel *bool
+	timerShow   *bool
+)
+
+var ErrInvalidTimerDuration = errors.New("duration must be at least one minute")
+
+var timerCmd = &cobra.Command{
+	GroupID: controlGroup.ID,
+	Use:     "timer [bulb name] [duration]",
+	Short:   "Set, show or cancel power off timer",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if *timerCancel || *timerShow {
+			return cobra.ExactArgs(1)(cmd, args)
+		}
+
+		return cobra.ExactArgs(2)(cmd, args)
+	},
+	ValidArgsFunction: func(_ *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return store.AllNames(), cobra.ShellCompDirectiveDefault
+		}
+
+		if len(args) == 1 {
+			return []string{"15m", "30m", "45m", "1h", "2h"}, cobra.ShellCompDirectiveDefault
+		}
+
+		return nil, cobra.ShellCompDirectiveDefault
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		control := app.NewControl(store, dialer, cmd)
+
+		switch {
+		case *timerCancel:
+			return control.CancelTimer(name)
+		case *timerShow:
+			return control.ShowTimer(name)
+		}
+
+		delay, err := parseTimerDuration(args[1])
+		if err != nil {
+			return fmt.Errorf("parse duration: %w", err)
+		}
+
+		return control.SetTimer(name, delay)
+	},
+}
+
+func parseTimerDuration(value string) (time.Duration, error) {
+	delay, err := time.ParseDuration(value)
+	if err != nil {
+		minutes, atoiErr := strconv.Atoi(value)
+		if atoiErr != nil {
+			return 0, err
+		}
+
+		delay = time.Duration(minutes) * time.Minute
+	}
+
+	if delay < time.Minute {
+		return 0, ErrInvalidTimerDuration
+	}
+
+	return delay, nil
+}
+
+func init() {
+	rootCmd.AddCommand(timerCmd)
+
+	timerCancel = timerCmd.Flags().Bool("cancel", false, "cancel power off timer")
+	timerShow = timerCmd.Flags().Bool("show", false, "show power off timer")
+	timerCmd.MarkFlagsMutuallyExclusive("cancel", "show")
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/pugkong/ylc/app"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimerCmd(t *testing.T) {
+	t.Run("it sets shows and cancels timer", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+
+		output, err := execute(t, "timer", "pikachu", "30m")
+		require.NoError(t, err)
+		require.Equal(t, "Power off in 30m0s\n", output)
+		require.Equal(t, "30", bulb.Prop("delayoff"))
+
+		output, err = execute(t, "timer", "pikachu", "--show")
+		require.NoError(t, err)
+		require.Equal(t, "Power off in 30m0s\n", output)
+
+		output, err = execute(t, "info", "pikachu")
+		require.NoError(t, err)
+		require.Contains(t, output, "Power off in: 30m0s\n")
+
+		_, err = execute(t, "timer", "pikachu", "--cancel")
+		require.NoError(t, err)
+		require.Equal(t, "0", bulb.Prop("delayoff"))
+
+		output, err = execute(t, "timer", "pikachu", "--show")
+		require.NoError(t, err)
+		require.Equal(t, "No timer\n", output)
+	})
+
+	t.Run("it accepts minutes and rounds durations up", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+
+		_, err := execute(t, "timer", "pikachu", "45")
+		require.NoError(t, err)
+		require.Equal(t, "45", bulb.Prop("delayoff"))
+
+		_, err = execute(t, "timer", "pikachu", "90s")
+		require.NoError(t, err)
+		require.Equal(t, "2", bulb.Prop("delayoff"))
+	})
+
+	t.Run("it handles invalid duration", func(t *testing.T) {
+		newStoreDir(t)
+
+		_, err := execute(t, "timer", "pikachu", "soon")
+		require.ErrorContains(t, err, "parse duration")
+
+		_, err = execute(t, "timer", "pikachu", "10s")
+		require.ErrorIs(t, err, ErrInvalidTimerDuration)
+	})
+
+	t.Run("it requires duration unless showing or cancelling", func(t *testing.T) {
+		newStoreDir(t)
+
+		_, err := execute(t, "timer", "pikachu")
+		require.ErrorContains(t, err, "accepts 2 arg(s)")
+	})
+
+	t.Run("it handles unknown bulb", func(t *testing.T) {
+		newStoreDir(t)
+
+		_, err := execute(t, "timer", "pikachu", "--show")
+		require.ErrorIs(t, err, app.ErrBulbNotFound)
+	})
+}
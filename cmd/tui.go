@@ -0,0 +1,234 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/pugkong/ylc/app"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+const tuiHelp = "↑/↓ select  space toggle  ←/→ bright  w/c warmer/cooler  1-8 color  q quit"
+
+type tuiKey int
+
+const (
+	tuiKeyNone tuiKey = iota
+	tuiKeyQuit
+	tuiKeyUp
+	tuiKeyDown
+	tuiKeyToggle
+	tuiKeyBrighter
+	tuiKeyDimmer
+	tuiKeyWarmer
+	tuiKeyCooler
+	tuiKeyColor
+)
+
+var tuiCmd = &cobra.Command{
+	GroupID: controlGroup.ID,
+	Use:     "tui",
+	Short:   "Show known bulbs with live state and control them with the keyboard",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		if file, ok := cmd.InOrStdin().(*os.File); ok && term.IsTerminal(int(file.Fd())) {
+			restore, err := setupTerminalScreen(cmd, int(file.Fd()))
+			if err != nil {
+				return err
+			}
+			defer restore()
+		}
+
+		dashboard := app.NewDashboard(store, dialer, cmd)
+		dashboard.Load()
+
+		ctx, cancel := context.WithCancel(cmd.Context())
+		var wg sync.WaitGroup
+		defer func() {
+			cancel()
+			wg.Wait()
+			dashboard.Wait()
+		}()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			dashboard.Watch(ctx)
+		}()
+
+		keys := make(chan tuiKeyPress)
+		go readTUIKeys(ctx, cmd.InOrStdin(), keys)
+
+		render := func() {
+			cmd.Print("\x1b[H\x1b[2J")
+			dashboard.Render()
+			cmd.Printf("\n%s\n", tuiHelp)
+		}
+
+		render()
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-dashboard.Changes():
+			case key, ok := <-keys:
+				if !ok || key.key == tuiKeyQuit {
+					return nil
+				}
+
+				handleTUIKey(dashboard, key)
+			}
+
+			render()
+		}
+	},
+}
+
+// setupTerminalScreen switches to the alternate screen in raw mode and
+// returns a func restoring the terminal.
+func setupTerminalScreen(cmd *cobra.Command, fd int) (func(), error) {
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return nil, fmt.Errorf("make terminal raw: %w", err)
+	}
+
+	out := cmd.OutOrStdout()
+	cmd.SetOut(crlfWriter{out})
+	cmd.Print("\x1b[?1049h\x1b[?25l")
+
+	return func() {
+		cmd.Print("\x1b[?25h\x1b[?1049l")
+		cmd.SetOut(out)
+		_ = term.Restore(fd, state)
+	}, nil
+}
+
+// crlfWriter ends lines with CRLF, as terminals in raw mode need.
+type crlfWriter struct {
+	io.Writer
+}
+
+func (w crlfWriter) Write(b []byte) (int, error) {
+	if _, err := w.Writer.Write(bytes.ReplaceAll(b, []byte("\n"), []byte("\r\n"))); err != nil {
+		return 0, err
+	}
+
+	return len(b), nil
+}
+
+type tuiKeyPress struct {
+	key   tuiKey
+	color int
+}
+
+func readTUIKeys(ctx context.Context, in io.Reader, keys chan<- tuiKeyPress) {
+	defer close(keys)
+
+	reader := bufio.NewReader(in)
+	for {
+		r, _, err := reader.ReadRune()
+		if err != nil {
+			return
+		}
+
+		key := parseTUIKey(r, reader)
+		if key.key == tuiKeyNone {
+			continue
+		}
+
+		select {
+		case keys <- key:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func parseTUIKey(r rune, reader *bufio.Reader) tuiKeyPress {
+	switch r {
+	case 'q', 3:
+		return tuiKeyPress{key: tuiKeyQuit}
+	case 'k':
+		return tuiKeyPress{key: tuiKeyUp}
+	case 'j':
+		return tuiKeyPress{key: tuiKeyDown}
+	case ' ', '\r':
+		return tuiKeyPress{key: tuiKeyToggle}
+	case '+', '=':
+		return tuiKeyPress{key: tuiKeyBrighter}
+	case '-':
+		return tuiKeyPress{key: tuiKeyDimmer}
+	case 'w':
+		return tuiKeyPress{key: tuiKeyWarmer}
+	case 'c':
+		return tuiKeyPress{key: tuiKeyCooler}
+	case '1', '2', '3', '4', '5', '6', '7', '8':
+		return tuiKeyPress{key: tuiKeyColor, color: int(r - '1')}
+	case 0x1b:
+		return parseTUIEscape(reader)
+	}
+
+	return tuiKeyPress{}
+}
+
+// parseTUIEscape reads arrow keys sent as ESC [ A to ESC [ D. Terminals send
+// the sequence at once, so a lone Esc does not wait for the next keys.
+func parseTUIEscape(reader *bufio.Reader) tuiKeyPress {
+	if reader.Buffered() < 2 {
+		return tuiKeyPress{}
+	}
+
+	if next, err := reader.Peek(2); err != nil || next[0] != '[' {
+		return tuiKeyPress{}
+	}
+
+	sequence := make([]byte, 2)
+	if _, err := io.ReadFull(reader, sequence); err != nil {
+		return tuiKeyPress{}
+	}
+
+	switch sequence[1] {
+	case 'A':
+		return tuiKeyPress{key: tuiKeyUp}
+	case 'B':
+		return tuiKeyPress{key: tuiKeyDown}
+	case 'C':
+		return tuiKeyPress{key: tuiKeyBrighter}
+	case 'D':
+		return tuiKeyPress{key: tuiKeyDimmer}
+	}
+
+	return tuiKeyPress{}
+}
+
+func handleTUIKey(dashboard *app.Dashboard, key tuiKeyPress) {
+	switch key.key {
+	case tuiKeyUp:
+		dashboard.Select(-1)
+	case tuiKeyDown:
+		dashboard.Select(1)
+	case tuiKeyToggle:
+		dashboard.Toggle()
+	case tuiKeyBrighter:
+		dashboard.Dim(1)
+	case tuiKeyDimmer:
+		dashboard.Dim(-1)
+	case tuiKeyWarmer:
+		dashboard.Warm(1)
+	case tuiKeyCooler:
+		dashboard.Warm(-1)
+	case tuiKeyColor:
+		dashboard.PickColor(key.color)
+	case tuiKeyNone, tuiKeyQuit:
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(tuiCmd)
+}
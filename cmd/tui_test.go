@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"io"
+	"net"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pugkong/ylc/app"
+	"github.com/pugkong/ylc/yeelight"
+	"github.com/stretchr/testify/require"
+)
+
+func executeTUI(t *testing.T, input io.Reader, args ...string) (string, error) {
+	t.Helper()
+
+	rootCmd.SetIn(input)
+	t.Cleanup(func() { rootCmd.SetIn(nil) })
+
+	return execute(t, append([]string{"tui"}, args...)...)
+}
+
+// startTUI runs the tui reading keys written to the returned pipe. The func
+// returned with it quits the tui and returns its output.
+func startTUI(t *testing.T, args ...string) (io.Writer, func() (string, error)) {
+	t.Helper()
+
+	input, keys := io.Pipe()
+	done := make(chan struct{})
+	var output string
+	var err error
+	go func() {
+		defer close(done)
+		output, err = executeTUI(t, input, args...)
+	}()
+
+	return keys, func() (string, error) {
+		// Renders follow changes made in the background, give them a moment.
+		time.Sleep(100 * time.Millisecond)
+		_, writeErr := keys.Write([]byte("q"))
+		require.NoError(t, writeErr)
+		<-done
+
+		return output, err
+	}
+}
+
+func TestTUICmd(t *testing.T) {
+	t.Run("it shows bulbs", func(t *testing.T) {
+		dir := newStoreDir(t)
+		pikachu := newBulb(t, "0xpikachu")
+		raichu := newBulb(t, "0xraichu")
+		raichu.SetProp("power", "off")
+		saveBulbs(t, dir,
+			app.Bulb{ID: "0xpikachu", Name: "pikachu", Addr: pikachu.Addr()},
+			app.Bulb{ID: "0xraichu", Name: "raichu", Addr: raichu.Addr()},
+			app.Bulb{ID: "0xeevee", Name: "eevee", Addr: unreachableAddr(t)},
+		)
+
+		_, quit := startTUI(t, "--attempts", "1")
+		require.Eventually(t, func() bool {
+			return len(pikachu.Methods()) > 0 && len(raichu.Methods()) > 0
+		}, time.Second, 10*time.Millisecond)
+
+		output, err := quit()
+		require.NoError(t, err)
+		require.Contains(t, output, "> eevee    unreachable\n")
+		require.Contains(t, output, "  pikachu  on   ██████████ 100%  \x1b[48;2;255;206;166m  \x1b[0m 4000K\n")
+		require.Contains(t, output, "  raichu   off  ··········\n")
+	})
+
+	t.Run("it controls selected bulb", func(t *testing.T) {
+		dir := newStoreDir(t)
+		pikachu := newBulb(t, "0xpikachu")
+		raichu := newBulb(t, "0xraichu")
+		saveBulbs(t, dir,
+			app.Bulb{ID: "0xpikachu", Name: "pikachu", Addr: pikachu.Addr()},
+			app.Bulb{ID: "0xraichu", Name: "raichu", Addr: raichu.Addr()},
+		)
+
+		_, err := executeTUI(t, strings.NewReader("j--w\x1b[A1k "))
+		require.NoError(t, err)
+		require.Equal(t, "80", raichu.Prop("bright"))
+		require.Equal(t, "3500", raichu.Prop("ct"))
+		require.Equal(t, "16711680", pikachu.Prop("rgb"))
+		require.Equal(t, "off", raichu.Prop("power"))
+	})
+
+	t.Run("it shows changes made elsewhere", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+
+		_, quit := startTUI(t)
+		require.Eventually(t, func() bool { return bulb.Connections() == 1 }, time.Second, 10*time.Millisecond)
+
+		conn, dialErr := net.Dial("tcp", bulb.Addr())
+		require.NoError(t, dialErr)
+		require.NoError(t, yeelight.NewController(conn).Bright(42, yeelight.EffectSudden, 0))
+		require.NoError(t, conn.Close())
+
+		output, err := quit()
+		require.NoError(t, err)
+		require.Contains(t, output, "> pikachu  on   ████······  42%")
+	})
+
+	t.Run("it shows errors", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+		bulb.SetError("toggle", -1, "general error")
+
+		keys, quit := startTUI(t)
+		_, err := keys.Write([]byte(" "))
+		require.NoError(t, err)
+		require.Eventually(t, func() bool {
+			return slices.Contains(bulb.Methods(), "toggle")
+		}, time.Second, 10*time.Millisecond)
+
+		output, err := quit()
+		require.NoError(t, err)
+		require.Contains(t, output, `toggle "pikachu" bulb power: bulb error: general error`)
+	})
+
+	t.Run("it shows bulbs before they answer", func(t *testing.T) {
+		dir := newStoreDir(t)
+		saveBulbs(t, dir, app.Bulb{ID: "0x01", Name: "pikachu", Addr: unreachableAddr(t)})
+
+		_, quit := startTUI(t)
+
+		output, err := quit()
+		require.NoError(t, err)
+		require.Contains(t, output, "> pikachu  loading\n")
+	})
+
+	t.Run("it takes keys after lone escape", func(t *testing.T) {
+		dir := newStoreDir(t)
+		bulb := newStoredBulb(t, dir, "pikachu")
+
+		keys, quit := startTUI(t)
+		_, err := keys.Write([]byte("\x1b"))
+		require.NoError(t, err)
+		_, err = keys.Write([]byte(" "))
+		require.NoError(t, err)
+		require.Eventually(t, func() bool {
+			return bulb.Prop("power") == "off"
+		}, time.Second, 10*time.Millisecond)
+
+		_, err = quit()
+		require.NoError(t, err)
+	})
+}
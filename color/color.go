@@ -0,0 +1,234 @@
+package color
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+//go:embed names.txt
+var namesTxt string
+
+var names = sync.OnceValue(func() map[string]int {
+	names := make(map[string]int)
+	for _, line := range strings.Split(namesTxt, "\n") {
+		name, hex, ok := strings.Cut(strings.TrimSpace(line), " ")
+		if !ok {
+			continue
+		}
+
+		value, err := strconv.ParseInt(hex, 16, 32)
+		if err != nil {
+			continue
+		}
+
+		names[name] = int(value)
+	}
+
+	return names
+})
+
+func Names() []string {
+	all := make([]string, 0, len(names()))
+	for name := range names() {
+		all = append(all, name)
+	}
+
+	slices.Sort(all)
+
+	return all
+}
+
+var (
+	ErrUnknownColor = errors.New("unknown color")
+	ErrOutOfRange   = errors.New("value out of range")
+)
+
+func Parse(value string) (int, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+
+	if rgb, ok := names()[value]; ok {
+		return rgb, nil
+	}
+
+	switch {
+	case strings.HasPrefix(value, "#"):
+		return parseHash(strings.TrimPrefix(value, "#"))
+	case strings.HasPrefix(value, "rgb(") && strings.HasSuffix(value, ")"):
+		return parseRGB(strings.TrimSuffix(strings.TrimPrefix(value, "rgb("), ")"))
+	case strings.HasPrefix(value, "hsl(") && strings.HasSuffix(value, ")"):
+		return parseHSL(strings.TrimSuffix(strings.TrimPrefix(value, "hsl("), ")"))
+	case strings.HasSuffix(value, "k"):
+		kelvin, err := strconv.Atoi(strings.TrimSuffix(value, "k"))
+		if err != nil {
+			return 0, fmt.Errorf("%w: %q", ErrUnknownColor, value)
+		}
+
+		return Kelvin(kelvin)
+	}
+
+	rgb, err := strconv.ParseUint(value, 16, 24)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrUnknownColor, value)
+	}
+
+	return int(rgb), nil
+}
+
+func parseHash(hex string) (int, error) {
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+
+	if len(hex) != 6 {
+		return 0, fmt.Errorf("%w: %q", ErrUnknownColor, "#"+hex)
+	}
+
+	rgb, err := strconv.ParseUint(hex, 16, 24)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrUnknownColor, "#"+hex)
+	}
+
+	return int(rgb), nil
+}
+
+func parseRGB(args string) (int, error) {
+	parts, err := splitArgs(args, 3)
+	if err != nil {
+		return 0, fmt.Errorf("parse rgb(%s): %w", args, err)
+	}
+
+	var components [3]int
+	for i, part := range parts {
+		components[i], err = parseComponent(part, 255)
+		if err != nil {
+			return 0, fmt.Errorf("parse rgb(%s): %w", args, err)
+		}
+	}
+
+	return components[0]<<16 | components[1]<<8 | components[2], nil
+}
+
+func parseHSL(args string) (int, error) {
+	parts, err := splitArgs(args, 3)
+	if err != nil {
+		return 0, fmt.Errorf("parse hsl(%s): %w", args, err)
+	}
+
+	hue, err := parseComponent(strings.TrimSuffix(parts[0], "deg"), 359)
+	if err != nil {
+		return 0, fmt.Errorf("parse hsl(%s): %w", args, err)
+	}
+
+	saturation, err := parseComponent(strings.TrimSuffix(parts[1], "%"), 100)
+	if err != nil {
+		return 0, fmt.Errorf("parse hsl(%s): %w", args, err)
+	}
+
+	lightness, err := parseComponent(strings.TrimSuffix(parts[2], "%"), 100)
+	if err != nil {
+		return 0, fmt.Errorf("parse hsl(%s): %w", args, err)
+	}
+
+	return HSL(hue, saturation, lightness), nil
+}
+
+func splitArgs(args string, count int) ([]string, error) {
+	parts := strings.Split(args, ",")
+	if len(parts) != count {
+		return nil, fmt.Errorf("%w: expected %d values", ErrUnknownColor, count)
+	}
+
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+
+	return parts, nil
+}
+
+func parseComponent(value string, limit int) (int, error) {
+	v, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("parse %q: %w", value, err)
+	}
+
+	if v < 0 || v > limit {
+		return 0, fmt.Errorf("%w: %d is not in [0, %d]", ErrOutOfRange, v, limit)
+	}
+
+	return v, nil
+}
+
+func HSL(hue, saturation, lightness int) int {
+	s := float64(saturation) / 100
+	l := float64(lightness) / 100
+
+	c := (1 - math.Abs(2*l-1)) * s
+	h := float64(hue) / 60
+	x := c * (1 - math.Abs(math.Mod(h, 2)-1))
+	m := l - c/2
+
+	var r, g, b float64
+	switch {
+	case h < 1:
+		r, g, b = c, x, 0
+	case h < 2:
+		r, g, b = x, c, 0
+	case h < 3:
+		r, g, b = 0, c, x
+	case h < 4:
+		r, g, b = 0, x, c
+	case h < 5:
+		r, g, b = x, 0, c
+	default:
+		r, g, b = c, 0, x
+	}
+
+	return channel((r+m)*255)<<16 | channel((g+m)*255)<<8 | channel((b+m)*255)
+}
+
+// The range of temperatures the Kelvin approximation is made for.
+const (
+	MinKelvin = 1000
+	MaxKelvin = 40000
+)
+
+// Kelvin approximates the color of a black body at the given temperature,
+// see https://tannerhelland.com/2012/09/18/convert-temperature-rgb-algorithm-code.html
+func Kelvin(kelvin int) (int, error) {
+	if kelvin < MinKelvin || kelvin > MaxKelvin {
+		return 0, fmt.Errorf("%w: %dK is not in %d-%dK", ErrOutOfRange, kelvin, MinKelvin, MaxKelvin)
+	}
+
+	temperature := float64(kelvin) / 100
+
+	var r, g, b float64
+
+	if temperature <= 66 {
+		r = 255
+		g = 99.4708025861*math.Log(temperature) - 161.1195681661
+	} else {
+		r = 329.698727446 * math.Pow(temperature-60, -0.1332047592)
+		g = 288.1221695283 * math.Pow(temperature-60, -0.0755148492)
+	}
+
+	switch {
+	case temperature >= 66:
+		b = 255
+	case temperature <= 19:
+		b = 0
+	default:
+		b = 138.5177312231*math.Log(temperature-10) - 305.0447927307
+	}
+
+	return channel(r)<<16 | channel(g)<<8 | channel(b), nil
+}
+
+func channel(value float64) int {
+	return int(math.Round(min(max(value, 0), 255)))
+}
@@ -0,0 +1,88 @@
+package color
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNames(t *testing.T) {
+	t.Run("it returns sorted css color names", func(t *testing.T) {
+		all := Names()
+
+		require.Greater(t, len(all), 140)
+		require.IsIncreasing(t, all)
+		require.Contains(t, all, "rebeccapurple")
+		require.Contains(t, all, "amber")
+	})
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		value string
+		rgb   int
+	}{
+		{"red", 0xff0000},
+		{" CornflowerBlue ", 0x6495ed},
+		{"amber", 0xffbf00},
+		{"#f00", 0xff0000},
+		{"#00ff7f", 0x00ff7f},
+		{"ff0000", 0xff0000},
+		{"ff", 0x0000ff},
+		{"rgb(255, 128, 0)", 0xff8000},
+		{"hsl(120, 100%, 50%)", 0x00ff00},
+		{"hsl(0deg,0%,100%)", 0xffffff},
+		{"6600K", 0xffffff},
+		{"1900k", 0xff8400},
+	}
+
+	for _, tt := range tests {
+		t.Run("it parses "+tt.value, func(t *testing.T) {
+			rgb, err := Parse(tt.value)
+			require.NoError(t, err)
+			require.Equal(t, tt.rgb, rgb, "%06x != %06x", tt.rgb, rgb)
+		})
+	}
+
+	errTests := []struct {
+		value string
+		err   error
+	}{
+		{"reddish", ErrUnknownColor},
+		{"#ff00", ErrUnknownColor},
+		{"1000000", ErrUnknownColor},
+		{"rgb(255, 0)", ErrUnknownColor},
+		{"rgb(256, 0, 0)", ErrOutOfRange},
+		{"hsl(360, 0%, 0%)", ErrOutOfRange},
+		{"warmk", ErrUnknownColor},
+		{"0k", ErrOutOfRange},
+		{"-2700K", ErrOutOfRange},
+	}
+
+	for _, tt := range errTests {
+		t.Run("it rejects "+tt.value, func(t *testing.T) {
+			_, err := Parse(tt.value)
+			require.ErrorIs(t, err, tt.err)
+		})
+	}
+}
+
+func TestKelvin(t *testing.T) {
+	t.Run("it gets warmer for lower temperatures", func(t *testing.T) {
+		warm, err := Kelvin(2700)
+		require.NoError(t, err)
+		cold, err := Kelvin(6500)
+		require.NoError(t, err)
+
+		require.Equal(t, 0xff, warm>>16)
+		require.Less(t, warm&0xff, cold&0xff)
+	})
+
+	for _, kelvin := range []int{0, -2700, 999, 40001} {
+		t.Run(fmt.Sprintf("it rejects %d", kelvin), func(t *testing.T) {
+			_, err := Kelvin(kelvin)
+			require.ErrorIs(t, err, ErrOutOfRange)
+		})
+	}
+}
@@ -0,0 +1,206 @@
+package cron
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed five field cron expression: minute, hour, day of
+// month, month and day of week.
+type Schedule struct {
+	minute, hour, dom, month, dow uint64
+
+	// Like in cron, a day matches either field when both day fields are
+	// restricted, and both fields otherwise.
+	domAny, dowAny bool
+}
+
+var (
+	ErrInvalidExpression = errors.New("invalid cron expression")
+	ErrOutOfRange        = errors.New("value out of range")
+)
+
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var (
+	monthNames = []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}
+	dayNames   = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+)
+
+type bounds struct {
+	name     string
+	min, max int
+	names    []string
+}
+
+var (
+	minuteBounds = bounds{name: "minute", min: 0, max: 59}
+	hourBounds   = bounds{name: "hour", min: 0, max: 23}
+	domBounds    = bounds{name: "day of month", min: 1, max: 31}
+	monthBounds  = bounds{name: "month", min: 1, max: 12, names: monthNames}
+	dowBounds    = bounds{name: "day of week", min: 0, max: 7, names: dayNames}
+)
+
+func Parse(expr string) (Schedule, error) {
+	expr = strings.ToLower(strings.TrimSpace(expr))
+	if macro, ok := macros[expr]; ok {
+		expr = macro
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return Schedule{}, fmt.Errorf("%w: %q: expected 5 fields, got %d", ErrInvalidExpression, expr, len(fields))
+	}
+
+	var schedule Schedule
+	var err error
+
+	parsers := []struct {
+		field  *uint64
+		bounds bounds
+	}{
+		{&schedule.minute, minuteBounds},
+		{&schedule.hour, hourBounds},
+		{&schedule.dom, domBounds},
+		{&schedule.month, monthBounds},
+		{&schedule.dow, dowBounds},
+	}
+	for i, parser := range parsers {
+		*parser.field, err = parseField(fields[i], parser.bounds)
+		if err != nil {
+			return Schedule{}, fmt.Errorf("parse %q: %w", expr, err)
+		}
+	}
+
+	// Sunday is both 0 and 7.
+	if schedule.dow&(1<<7) != 0 {
+		schedule.dow |= 1
+	}
+
+	// Like in cron, a field starting with *, as */2 does, is unrestricted.
+	schedule.domAny = strings.HasPrefix(fields[2], "*") || fields[2] == "?"
+	schedule.dowAny = strings.HasPrefix(fields[4], "*") || fields[4] == "?"
+
+	return schedule, nil
+}
+
+func parseField(field string, b bounds) (uint64, error) {
+	var bits uint64
+
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepPart)
+			if err != nil || step < 1 {
+				return 0, fmt.Errorf("%w: %s step %q", ErrInvalidExpression, b.name, stepPart)
+			}
+		}
+
+		low, high := b.min, b.max
+		switch {
+		case rangePart == "*" || rangePart == "?":
+		case strings.Contains(rangePart, "-"):
+			lowPart, highPart, _ := strings.Cut(rangePart, "-")
+
+			var err error
+			if low, err = parseValue(lowPart, b); err != nil {
+				return 0, err
+			}
+
+			if high, err = parseValue(highPart, b); err != nil {
+				return 0, err
+			}
+
+			if low > high {
+				return 0, fmt.Errorf("%w: %s range %q", ErrInvalidExpression, b.name, rangePart)
+			}
+		default:
+			var err error
+			if low, err = parseValue(rangePart, b); err != nil {
+				return 0, err
+			}
+
+			if !hasStep {
+				high = low
+			}
+		}
+
+		for value := low; value <= high; value += step {
+			bits |= 1 << value
+		}
+	}
+
+	return bits, nil
+}
+
+func parseValue(value string, b bounds) (int, error) {
+	for i, name := range b.names {
+		if value == name {
+			return i + b.min, nil
+		}
+	}
+
+	v, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s %q", ErrInvalidExpression, b.name, value)
+	}
+
+	if v < b.min || v > b.max {
+		return 0, fmt.Errorf("%w: %s %d is not in [%d, %d]", ErrOutOfRange, b.name, v, b.min, b.max)
+	}
+
+	return v, nil
+}
+
+// maxYears bounds the search for expressions which never match, like
+// the 31st of February.
+const maxYears = 5
+
+// Next returns the first time after t matching the schedule, or the zero time
+// if there is none.
+func (s Schedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(maxYears, 0, 0)
+
+	for t.Before(limit) {
+		switch {
+		case s.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !s.matchDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case s.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case s.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+func (s Schedule) matchDay(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+
+	if s.domAny || s.dowAny {
+		return dom && dow
+	}
+
+	return dom || dow
+}
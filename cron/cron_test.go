@@ -0,0 +1,68 @@
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchedule_Next(t *testing.T) {
+	// Monday.
+	start := time.Date(2024, time.June, 3, 6, 30, 15, 0, time.UTC)
+
+	tests := []struct {
+		expr string
+		next time.Time
+	}{
+		{"* * * * *", time.Date(2024, time.June, 3, 6, 31, 0, 0, time.UTC)},
+		{"0 7 * * 1-5", time.Date(2024, time.June, 3, 7, 0, 0, 0, time.UTC)},
+		{"0 7 * * sat,sun", time.Date(2024, time.June, 8, 7, 0, 0, 0, time.UTC)},
+		{"*/20 * * * *", time.Date(2024, time.June, 3, 6, 40, 0, 0, time.UTC)},
+		{"15 6 * * *", time.Date(2024, time.June, 4, 6, 15, 0, 0, time.UTC)},
+		{"0 0 1 jan *", time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 12 13 * 5", time.Date(2024, time.June, 7, 12, 0, 0, 0, time.UTC)},
+		{"0 22 * * 7", time.Date(2024, time.June, 9, 22, 0, 0, 0, time.UTC)},
+		{"30 8-18/4 * * *", time.Date(2024, time.June, 3, 8, 30, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2024, time.June, 3, 7, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 7 */2 * 5", time.Date(2024, time.June, 7, 7, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run("it finds next run of "+tt.expr, func(t *testing.T) {
+			schedule, err := Parse(tt.expr)
+			require.NoError(t, err)
+			require.Equal(t, tt.next, schedule.Next(start))
+		})
+	}
+
+	t.Run("it returns zero time for impossible date", func(t *testing.T) {
+		schedule, err := Parse("0 0 31 2 *")
+		require.NoError(t, err)
+		require.True(t, schedule.Next(start).IsZero())
+	})
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		expr string
+		err  error
+	}{
+		{"0 7 * *", ErrInvalidExpression},
+		{"60 * * * *", ErrOutOfRange},
+		{"0 24 * * *", ErrOutOfRange},
+		{"0 0 0 * *", ErrOutOfRange},
+		{"0 0 * 13 *", ErrOutOfRange},
+		{"*/0 * * * *", ErrInvalidExpression},
+		{"0 5-2 * * *", ErrInvalidExpression},
+		{"0 0 * * someday", ErrInvalidExpression},
+	}
+
+	for _, tt := range tests {
+		t.Run("it rejects "+tt.expr, func(t *testing.T) {
+			_, err := Parse(tt.expr)
+			require.ErrorIs(t, err, tt.err)
+		})
+	}
+}
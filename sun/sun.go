@@ -0,0 +1,71 @@
+package sun
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+var (
+	ErrPolarDay   = errors.New("sun does not set")
+	ErrPolarNight = errors.New("sun does not rise")
+)
+
+// Times returns sunrise and sunset on the calendar day of date in its
+// location, for an observer at the given latitude and longitude in degrees,
+// north and east positive. It follows the sunrise equation, see
+// https://en.wikipedia.org/wiki/Sunrise_equation, which is good to a minute
+// or two away from the poles.
+func Times(date time.Time, latitude, longitude float64) (sunrise, sunset time.Time, err error) {
+	// The solar day is the one whose transit is nearest local noon, which
+	// may be a UTC day off where the zone is far from the longitude.
+	noon := time.Date(date.Year(), date.Month(), date.Day(), 12, 0, 0, 0, date.Location())
+	day := math.Round(julianDate(noon) - j2000 + longitude/360)
+
+	meanSolarTime := day - longitude/360
+	anomaly := math.Mod(357.5291+0.98560028*meanSolarTime, 360)
+	center := 1.9148*sin(anomaly) + 0.02*sin(2*anomaly) + 0.0003*sin(3*anomaly)
+	eclipticLongitude := math.Mod(anomaly+center+180+102.9372, 360)
+	transit := j2000 + meanSolarTime + 0.0053*sin(anomaly) - 0.0069*sin(2*eclipticLongitude)
+
+	declination := math.Asin(sin(eclipticLongitude) * sin(23.4397))
+	cosHourAngle := (sin(-0.833) - sin(latitude)*math.Sin(declination)) /
+		(cos(latitude) * math.Cos(declination))
+
+	switch {
+	case cosHourAngle > 1:
+		return time.Time{}, time.Time{}, ErrPolarNight
+	case cosHourAngle < -1:
+		return time.Time{}, time.Time{}, ErrPolarDay
+	}
+
+	hourAngle := math.Acos(cosHourAngle) * 180 / math.Pi
+
+	sunrise = fromJulianDate(transit - hourAngle/360).In(date.Location())
+	sunset = fromJulianDate(transit + hourAngle/360).In(date.Location())
+
+	return sunrise, sunset, nil
+}
+
+const (
+	j2000     = 2451545.0
+	unixEpoch = 2440587.5
+)
+
+func julianDate(t time.Time) float64 {
+	return float64(t.Unix())/86400 + unixEpoch
+}
+
+func fromJulianDate(jd float64) time.Time {
+	seconds := (jd - unixEpoch) * 86400
+
+	return time.Unix(0, int64(seconds*float64(time.Second))).Round(time.Second)
+}
+
+func sin(degrees float64) float64 {
+	return math.Sin(degrees * math.Pi / 180)
+}
+
+func cos(degrees float64) float64 {
+	return math.Cos(degrees * math.Pi / 180)
+}
@@ -0,0 +1,76 @@
+package sun
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimes(t *testing.T) {
+	cest := time.FixedZone("CEST", 2*60*60)
+	est := time.FixedZone("EST", -5*60*60)
+	aest := time.FixedZone("AEST", 10*60*60)
+	lint := time.FixedZone("LINT", 14*60*60)
+	wst := time.FixedZone("WST", 13*60*60)
+
+	tests := []struct {
+		name      string
+		date      time.Time
+		latitude  float64
+		longitude float64
+		sunrise   time.Time
+		sunset    time.Time
+	}{
+		{
+			"berlin at summer solstice",
+			time.Date(2024, time.June, 21, 0, 0, 0, 0, cest), 52.52, 13.405,
+			time.Date(2024, time.June, 21, 4, 43, 0, 0, cest),
+			time.Date(2024, time.June, 21, 21, 33, 0, 0, cest),
+		},
+		{
+			"new york at winter solstice",
+			time.Date(2024, time.December, 21, 0, 0, 0, 0, est), 40.7128, -74.006,
+			time.Date(2024, time.December, 21, 7, 16, 0, 0, est),
+			time.Date(2024, time.December, 21, 16, 32, 0, 0, est),
+		},
+		{
+			"sydney in winter",
+			time.Date(2024, time.July, 1, 0, 0, 0, 0, aest), -33.8688, 151.2093,
+			time.Date(2024, time.July, 1, 7, 1, 0, 0, aest),
+			time.Date(2024, time.July, 1, 16, 57, 0, 0, aest),
+		},
+		{
+			"kiritimati across the date line",
+			time.Date(2024, time.March, 1, 0, 0, 0, 0, lint), 1.87, -157.4,
+			time.Date(2024, time.March, 1, 6, 40, 0, 0, lint),
+			time.Date(2024, time.March, 1, 18, 45, 0, 0, lint),
+		},
+		{
+			"apia across the date line",
+			time.Date(2024, time.March, 1, 0, 0, 0, 0, wst), -13.83, -171.76,
+			time.Date(2024, time.March, 1, 6, 29, 0, 0, wst),
+			time.Date(2024, time.March, 1, 18, 51, 0, 0, wst),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run("it computes "+tt.name, func(t *testing.T) {
+			sunrise, sunset, err := Times(tt.date, tt.latitude, tt.longitude)
+			require.NoError(t, err)
+			require.WithinDuration(t, tt.sunrise, sunrise, 2*time.Minute)
+			require.WithinDuration(t, tt.sunset, sunset, 2*time.Minute)
+			require.Equal(t, tt.date.Location(), sunrise.Location())
+		})
+	}
+
+	t.Run("it detects polar day", func(t *testing.T) {
+		_, _, err := Times(time.Date(2024, time.June, 21, 0, 0, 0, 0, time.UTC), 69.6492, 18.9553)
+		require.ErrorIs(t, err, ErrPolarDay)
+	})
+
+	t.Run("it detects polar night", func(t *testing.T) {
+		_, _, err := Times(time.Date(2024, time.December, 21, 0, 0, 0, 0, time.UTC), 69.6492, 18.9553)
+		require.ErrorIs(t, err, ErrPolarNight)
+	})
+}
@@ -0,0 +1,64 @@
+package yeelight
+
+type AdjustAction string
+
+const (
+	AdjustActionIncrease AdjustAction = "increase"
+	AdjustActionDecrease AdjustAction = "decrease"
+	AdjustActionCircle   AdjustAction = "circle"
+)
+
+type AdjustProp string
+
+const (
+	AdjustPropBright           AdjustProp = "bright"
+	AdjustPropColorTemperature AdjustProp = "ct"
+	AdjustPropColor            AdjustProp = "color"
+)
+
+func (c *Controller) Adjust(action AdjustAction, prop AdjustProp) error {
+	_, err := c.sendCommand(command{Method: "set_adjust", Params: []any{action, prop}})
+
+	return err
+}
+
+func (c *Controller) BackgroundAdjust(action AdjustAction, prop AdjustProp) error {
+	_, err := c.sendCommand(command{Method: "bg_set_adjust", Params: []any{action, prop}})
+
+	return err
+}
+
+func (c *Controller) AdjustBright(percentage, duration int) error {
+	return c.adjust("adjust_bright", percentage, duration)
+}
+
+func (c *Controller) BackgroundAdjustBright(percentage, duration int) error {
+	return c.adjust("bg_adjust_bright", percentage, duration)
+}
+
+func (c *Controller) AdjustColorTemperature(percentage, duration int) error {
+	return c.adjust("adjust_ct", percentage, duration)
+}
+
+func (c *Controller) BackgroundAdjustColorTemperature(percentage, duration int) error {
+	return c.adjust("bg_adjust_ct", percentage, duration)
+}
+
+func (c *Controller) AdjustColor(percentage, duration int) error {
+	return c.adjust("adjust_color", percentage, duration)
+}
+
+func (c *Controller) BackgroundAdjustColor(percentage, duration int) error {
+	return c.adjust("bg_adjust_color", percentage, duration)
+}
+
+func (c *Controller) adjust(method string, percentage, duration int) error {
+	params, err := c.checkAll(adjustValue(percentage), durationValue(EffectSudden, duration))
+	if err != nil {
+		return err
+	}
+
+	_, err = c.sendCommand(command{Method: method, Params: params})
+
+	return err
+}
@@ -2,10 +2,13 @@ package yeelight
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
 	"strconv"
+	"strings"
 )
 
 type colorMode string
@@ -16,6 +19,13 @@ const (
 	ColorModeHSV         = "3"
 )
 
+type activeMode string
+
+const (
+	ActiveModeDaylight  = "0"
+	ActiveModeMoonlight = "1"
+)
+
 type rgb string
 
 func (r rgb) Int() (int, error) {
@@ -43,6 +53,19 @@ type BulbInfo struct {
 	BackgroundRGB              rgb
 	BackgroundHUE              string
 	BackgroundSaturation       string
+
+	ActiveMode       activeMode
+	NightLightBright string
+
+	DelayOff string
+}
+
+func (i BulbInfo) HasBackground() bool {
+	return i.BackgroundPower != ""
+}
+
+func (i BulbInfo) HasNightLight() bool {
+	return i.ActiveMode != ""
 }
 
 type TCPConn interface {
@@ -51,39 +74,52 @@ type TCPConn interface {
 }
 
 type Controller struct {
+	// Clamp brings out of range values to the nearest bound instead of
+	// failing with a RangeError.
+	Clamp bool
+
 	conn          TCPConn
+	reader        *bufio.Reader
 	nextCommandID int
 }
 
 func NewController(conn TCPConn) *Controller {
-	return &Controller{conn: conn, nextCommandID: 1}
+	return &Controller{conn: conn, reader: bufio.NewReader(conn), nextCommandID: 1}
+}
+
+var infoProps = []any{
+	"power",
+	"bright",
+	"color_mode",
+	"ct",
+	"rgb",
+	"hue",
+	"sat",
+
+	"bg_power",
+	"bg_bright",
+	"bg_lmode",
+	"bg_ct",
+	"bg_rgb",
+	"bg_hue",
+	"bg_sat",
+
+	"active_mode",
+	"nl_br",
+
+	"delayoff",
 }
 
 func (c *Controller) Info() (BulbInfo, error) {
-	result, err := c.sendCommand(command{
-		Method: "get_prop",
-		Params: []any{
-			"power",
-			"bright",
-			"color_mode",
-			"ct",
-			"rgb",
-			"hue",
-			"sat",
-
-			"bg_power",
-			"bg_bright",
-			"bg_lmode",
-			"bg_ct",
-			"bg_rgb",
-			"bg_hue",
-			"bg_sat",
-		},
-	})
+	result, err := c.sendCommand(command{Method: "get_prop", Params: infoProps})
 	if err != nil {
 		return BulbInfo{}, err
 	}
 
+	if len(result) != len(infoProps) {
+		return BulbInfo{}, fmt.Errorf("%w: got %d props", ErrUnexpectedResponse, len(result))
+	}
+
 	info := BulbInfo{
 		Power:            result[0],
 		Bright:           result[1],
@@ -100,11 +136,22 @@ func (c *Controller) Info() (BulbInfo, error) {
 		BackgroundRGB:              rgb(result[11]),
 		BackgroundHUE:              result[12],
 		BackgroundSaturation:       result[13],
+
+		ActiveMode:       activeMode(result[14]),
+		NightLightBright: result[15],
+
+		DelayOff: result[16],
 	}
 
 	return info, nil
 }
 
+func (c *Controller) Toggle() error {
+	_, err := c.sendCommand(command{Method: "toggle", Params: []any{}})
+
+	return err
+}
+
 func (c *Controller) PowerToggle() error {
 	_, err := c.sendCommand(command{Method: "dev_toggle", Params: []any{}})
 
@@ -117,45 +164,113 @@ func (c *Controller) BackgroundToggle() error {
 	return err
 }
 
+type Power string
+
+const (
+	PowerOn  Power = "on"
+	PowerOff Power = "off"
+)
+
+type PowerMode int
+
+const (
+	PowerModeNormal      PowerMode = 0
+	PowerModeTemperature PowerMode = 1
+	PowerModeRGB         PowerMode = 2
+	PowerModeHSV         PowerMode = 3
+	PowerModeColorFlow   PowerMode = 4
+	PowerModeNightLight  PowerMode = 5
+)
+
+func (c *Controller) Power(power Power, effect Effect, duration int, mode PowerMode) error {
+	return c.setPower("set_power", power, effect, duration, mode)
+}
+
+func (c *Controller) BackgroundPower(power Power, effect Effect, duration int, mode PowerMode) error {
+	return c.setPower("bg_set_power", power, effect, duration, mode)
+}
+
 func (c *Controller) Bright(value int, effect Effect, duration int) error {
-	_, err := c.sendCommand(command{Method: "set_bright", Params: []any{value, effect, duration}})
+	return c.set("set_bright", effect, duration, brightValue(value))
+}
+
+func (c *Controller) setPower(method string, power Power, effect Effect, duration int, mode PowerMode) error {
+	duration, err := c.check(durationValue(effect, duration))
+	if err != nil {
+		return err
+	}
+
+	_, err = c.sendCommand(command{Method: method, Params: []any{power, effect, duration, mode}})
 
 	return err
 }
 
 func (c *Controller) BackgroundBright(value int, effect Effect, duration int) error {
-	_, err := c.sendCommand(command{Method: "bg_set_bright", Params: []any{value, effect, duration}})
-
-	return err
+	return c.set("bg_set_bright", effect, duration, brightValue(value))
 }
 
 type Effect string
 
-var (
+const (
 	EffectSudden Effect = "sudden"
 	EffectSmooth Effect = "smooth"
 )
 
-func (c *Controller) ColorTemperature(value int, effect Effect, duration int) error {
-	_, err := c.sendCommand(command{Method: "set_ct_abx", Params: []any{value, effect, duration}})
+const (
+	MinColorTemperature = 1700
+	MaxColorTemperature = 6500
+)
 
-	return err
+func (c *Controller) ColorTemperature(value int, effect Effect, duration int) error {
+	return c.set("set_ct_abx", effect, duration, colorTemperatureValue(value))
 }
 
 func (c *Controller) BackgroundColorTemperature(value int, effect Effect, duration int) error {
-	_, err := c.sendCommand(command{Method: "bg_set_ct_abx", Params: []any{value, effect, duration}})
+	return c.set("bg_set_ct_abx", effect, duration, colorTemperatureValue(value))
+}
+
+func (c *Controller) RGB(value int, effect Effect, duration int) error {
+	return c.set("set_rgb", effect, duration, rgbValue(value))
+}
+
+func (c *Controller) BackgroundRGB(value int, effect Effect, duration int) error {
+	return c.set("bg_set_rgb", effect, duration, rgbValue(value))
+}
+
+func (c *Controller) SaveDefault() error {
+	_, err := c.sendCommand(command{Method: "set_default", Params: []any{}})
 
 	return err
 }
 
-func (c *Controller) RGB(value int, effect Effect, duration int) error {
-	_, err := c.sendCommand(command{Method: "set_rgb", Params: []any{value, effect, duration}})
+func (c *Controller) BackgroundSaveDefault() error {
+	_, err := c.sendCommand(command{Method: "bg_set_default", Params: []any{}})
 
 	return err
 }
 
-func (c *Controller) BackgroundRGB(value int, effect Effect, duration int) error {
-	_, err := c.sendCommand(command{Method: "bg_set_rgb", Params: []any{value, effect, duration}})
+func (c *Controller) HSV(hue, saturation int, effect Effect, duration int) error {
+	return c.set("set_hsv", effect, duration, hueValue(hue), saturationValue(saturation))
+}
+
+func (c *Controller) BackgroundHSV(hue, saturation int, effect Effect, duration int) error {
+	return c.set("bg_set_hsv", effect, duration, hueValue(hue), saturationValue(saturation))
+}
+
+// set checks the values and sends them followed by the effect and its
+// duration.
+func (c *Controller) set(method string, effect Effect, duration int, values ...rangeValue) error {
+	params, err := c.checkAll(values...)
+	if err != nil {
+		return err
+	}
+
+	duration, err = c.check(durationValue(effect, duration))
+	if err != nil {
+		return err
+	}
+
+	_, err = c.sendCommand(command{Method: method, Params: append(params, effect, duration)})
 
 	return err
 }
@@ -167,19 +282,81 @@ type command struct {
 }
 
 type result struct {
-	ID     int      `json:"id"`
-	Result []string `json:"result"`
-	Error  struct {
-		Message string `json:"message"`
-	} `json:"error"`
+	ID     int             `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *BulbError      `json:"error"`
 }
 
 var (
 	ErrResponseTooLong = errors.New("response is too long")
 	ErrBulbResponse    = errors.New("bulb error")
+
+	// ErrReconnected is returned by a connection from Read after it
+	// reconnected, so the controller drops what it read from the old one.
+	ErrReconnected = errors.New("reconnected")
+
+	ErrUnexpectedResponse = errors.New("unexpected response")
+)
+
+var (
+	ErrMethodNotSupported = errors.New("method not supported")
+	ErrInvalidParams      = errors.New("invalid params")
+	ErrQuotaExceeded      = errors.New("client quota exceeded")
+	ErrNotPoweredOn       = errors.New("not powered on")
 )
 
+// BulbError is an error object returned by a bulb. Most firmwares report
+// everything with the general code and other codes are not documented, so
+// the message alone picks the kind.
+type BulbError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *BulbError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrBulbResponse, e.Message)
+}
+
+func (e *BulbError) Unwrap() []error {
+	if kind := e.kind(); kind != nil {
+		return []error{ErrBulbResponse, kind}
+	}
+
+	return []error{ErrBulbResponse}
+}
+
+func (e *BulbError) kind() error {
+	message := strings.ToLower(e.Message)
+
+	switch {
+	case strings.Contains(message, "quota exceeded"):
+		return ErrQuotaExceeded
+	case strings.Contains(message, "not supported"), strings.Contains(message, "unsupported method"):
+		return ErrMethodNotSupported
+	case strings.Contains(message, "invalid param"):
+		return ErrInvalidParams
+	case strings.Contains(message, "not powered on"), strings.Contains(message, "power off"):
+		return ErrNotPoweredOn
+	}
+
+	return nil
+}
+
 func (c *Controller) sendCommand(command command) ([]string, error) {
+	data, err := c.call(command)
+	if err != nil {
+		return nil, err
+	}
+
+	var values []string
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("parse result %q: %w", string(data), err)
+	}
+
+	return values, nil
+}
+
+func (c *Controller) call(command command) (json.RawMessage, error) {
 	command.ID = c.nextCommandID
 	c.nextCommandID++
 
@@ -188,35 +365,52 @@ func (c *Controller) sendCommand(command command) ([]string, error) {
 		return nil, fmt.Errorf("prepare command: %w", err)
 	}
 
+	slog.Debug("send command", "id", command.ID, "method", command.Method)
+
 	data = append(data, '\r', '\n')
 	if _, err := c.conn.Write(data); err != nil {
 		return nil, fmt.Errorf("send command: %w", err)
 	}
 
-	reader := bufio.NewReader(c.conn)
 	for {
-		line, prefix, err := reader.ReadLine()
-		if err != nil {
-			return nil, fmt.Errorf("read response: %w", err)
+		// ReadSlice keeps the error of a line cut by a failed connection,
+		// which ReadLine would return as a complete one.
+		line, err := c.reader.ReadSlice('\n')
+		if errors.Is(err, ErrReconnected) {
+			slog.Debug("drop data of old connection", "id", command.ID)
+			c.reader.Reset(c.conn)
+
+			continue
 		}
 
-		if prefix {
+		if errors.Is(err, bufio.ErrBufferFull) {
 			return nil, ErrResponseTooLong
 		}
 
+		if err != nil {
+			return nil, fmt.Errorf("read response: %w", err)
+		}
+
+		line = bytes.TrimRight(line, "\r\n")
+
 		var result result
 		if err := json.Unmarshal(line, &result); err != nil {
 			return nil, fmt.Errorf("parse response %q: %w", string(line), err)
 		}
 
 		if result.ID != command.ID {
+			slog.Debug("skip unmatched line", "id", command.ID, "line", string(line))
+
 			continue
 		}
 
-		if result.Error.Message == "" {
+		if result.Error == nil {
 			return result.Result, nil
 		}
 
-		return nil, fmt.Errorf("%w: %v", ErrBulbResponse, result.Error.Message)
+		slog.Debug("bulb error", "id", command.ID, "method", command.Method,
+			"code", result.Error.Code, "message", result.Error.Message)
+
+		return nil, result.Error
 	}
 }
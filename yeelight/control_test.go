@@ -1,33 +1,132 @@
 package yeelight
 
 import (
+	"path"
 	"testing"
 
+	"github.com/pugkong/ylc/yeelight/yeelighttest"
 	"github.com/stretchr/testify/require"
 )
 
-type TCPConnDummy struct {
-	output []byte
-}
+// replayController replays a fixture from testdata. The fixtures in
+// testdata/synthetic are written by hand in the recorder format from the
+// protocol spec, as no real bulb was at hand to record them. Sessions
+// recorded from real bulbs go right into testdata.
+func replayController(t *testing.T, fixture, addr string) *Controller {
+	t.Helper()
+
+	replay, err := yeelighttest.LoadReplay(path.Join("testdata", fixture))
+	require.NoError(t, err)
+	t.Cleanup(func() { require.Zero(t, replay.Remaining(), "not all recorded events were replayed") })
 
-func (t *TCPConnDummy) Write(data []byte) (int, error) {
-	return len(data), nil
+	return NewController(replay.TCPConn(addr))
 }
 
-func (t *TCPConnDummy) Read(buffer []byte) (int, error) {
-	return copy(buffer, t.output), nil
+func TestController(t *testing.T) {
+	t.Run("it controls color bulb", func(t *testing.T) {
+		controller := replayController(t, "synthetic/color-bulb.jsonl", "192.168.1.23:55443")
+
+		info, err := controller.Info()
+		require.NoError(t, err)
+		require.Equal(t, BulbInfo{
+			Power:            "on",
+			Bright:           "54",
+			ColorMode:        ColorModeTemperature,
+			ColorTemperature: "3500",
+			RGB:              "16711680",
+			HUE:              "359",
+			Saturation:       "100",
+			DelayOff:         "0",
+		}, info)
+		require.False(t, info.HasBackground())
+		require.False(t, info.HasNightLight())
+
+		require.NoError(t, controller.Bright(80, EffectSmooth, 500))
+		require.NoError(t, controller.RGB(0xff7f00, EffectSudden, 0))
+	})
+
+	t.Run("it controls ceiling light with background and night light", func(t *testing.T) {
+		controller := replayController(t, "synthetic/ceiling-light.jsonl", "192.168.1.42:55443")
+
+		info, err := controller.Info()
+		require.NoError(t, err)
+		require.True(t, info.HasBackground())
+		require.True(t, info.HasNightLight())
+		require.Equal(t, colorMode(ColorModeRGB), info.BackgroundColorMode)
+		require.Equal(t, rgb("255"), info.BackgroundRGB)
+		require.Equal(t, activeMode(ActiveModeMoonlight), info.ActiveMode)
+		require.Equal(t, "20", info.NightLightBright)
+		require.Equal(t, "15", info.DelayOff)
+
+		require.NoError(t, controller.BackgroundRGB(0x00ff00, EffectSmooth, 300))
+		require.NoError(t, controller.Power(PowerOn, EffectSmooth, 500, PowerModeNightLight))
+	})
+
+	t.Run("it controls mono bulb", func(t *testing.T) {
+		controller := replayController(t, "synthetic/mono-bulb.jsonl", "192.168.1.7:55443")
+
+		info, err := controller.Info()
+		require.NoError(t, err)
+		require.Equal(t, "off", info.Power)
+		require.Empty(t, info.ColorMode)
+		require.Empty(t, info.ColorTemperature)
+
+		err = controller.RGB(0xff0000, EffectSudden, 0)
+		require.ErrorIs(t, err, ErrBulbResponse)
+		require.ErrorIs(t, err, ErrMethodNotSupported)
+
+		var bulbErr *BulbError
+		require.ErrorAs(t, err, &bulbErr)
+		require.Equal(t, BulbError{Code: -1, Message: "method not supported"}, *bulbErr)
+
+		require.NoError(t, controller.Toggle())
+	})
 }
 
 func TestController_sendCommand(t *testing.T) {
 	t.Run("it handles multiple messages on single conn.read", func(t *testing.T) {
-		output := "{\"method\":\"props\",\"params\":{\"bg_power\":\"off\",\"power\":\"off\"}}\r\n"
-		output += "{\"id\":1,\"result\":[\"ok\"]}\r\n"
-
-		conn := &TCPConnDummy{output: []byte(output)}
-		controller := NewController(conn)
-		result, err := controller.sendCommand(command{})
+		controller := replayController(t, "synthetic/ceiling-light-toggle.jsonl", "192.168.1.42:55443")
 
+		result, err := controller.sendCommand(command{Method: "dev_toggle", Params: []any{}})
 		require.NoError(t, err)
 		require.Equal(t, []string{"ok"}, result)
 	})
 }
+
+func TestBulbError(t *testing.T) {
+	tests := []struct {
+		err  BulbError
+		kind error
+	}{
+		{BulbError{Code: -1, Message: "method not supported"}, ErrMethodNotSupported},
+		{BulbError{Code: -1, Message: "unsupported method"}, ErrMethodNotSupported},
+		{BulbError{Code: -1, Message: "client quota exceeded"}, ErrQuotaExceeded},
+		{BulbError{Code: -1, Message: "invalid params"}, ErrInvalidParams},
+		{BulbError{Code: -1, Message: "bulb not powered on"}, ErrNotPoweredOn},
+		{BulbError{Code: -1, Message: "device power off"}, ErrNotPoweredOn},
+	}
+
+	for _, tt := range tests {
+		t.Run("it maps "+tt.err.Message, func(t *testing.T) {
+			err := &tt.err
+			require.ErrorIs(t, err, ErrBulbResponse)
+			require.ErrorIs(t, err, tt.kind)
+		})
+	}
+
+	t.Run("it keeps unknown errors generic", func(t *testing.T) {
+		err := &BulbError{Code: -1, Message: "general error"}
+		require.ErrorIs(t, err, ErrBulbResponse)
+		require.NotErrorIs(t, err, ErrNotPoweredOn)
+		require.EqualError(t, err, "bulb error: general error")
+	})
+
+	t.Run("it does not map undocumented codes", func(t *testing.T) {
+		for _, code := range []int{-5000, -5001} {
+			err := &BulbError{Code: code, Message: "general error"}
+			require.ErrorIs(t, err, ErrBulbResponse)
+			require.NotErrorIs(t, err, ErrNotPoweredOn)
+			require.NotErrorIs(t, err, ErrInvalidParams)
+		}
+	})
+}
@@ -0,0 +1,42 @@
+package yeelight
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+type CronType int
+
+const CronTypePowerOff CronType = 0
+
+type Cron struct {
+	Type  CronType `json:"type"`
+	Delay int      `json:"delay"`
+	Mix   int      `json:"mix"`
+}
+
+func (c *Controller) AddCron(cronType CronType, minutes int) error {
+	_, err := c.sendCommand(command{Method: "cron_add", Params: []any{cronType, minutes}})
+
+	return err
+}
+
+func (c *Controller) Crons(cronType CronType) ([]Cron, error) {
+	data, err := c.call(command{Method: "cron_get", Params: []any{cronType}})
+	if err != nil {
+		return nil, err
+	}
+
+	var crons []Cron
+	if err := json.Unmarshal(data, &crons); err != nil {
+		return nil, fmt.Errorf("parse crons %q: %w", string(data), err)
+	}
+
+	return crons, nil
+}
+
+func (c *Controller) DeleteCron(cronType CronType) error {
+	_, err := c.sendCommand(command{Method: "cron_del", Params: []any{cronType}})
+
+	return err
+}
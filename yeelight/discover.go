@@ -3,6 +3,7 @@ package yeelight
 import (
 	"bytes"
 	"fmt"
+	"log/slog"
 	"net"
 	"strings"
 )
@@ -14,26 +15,23 @@ type Bulb struct {
 
 type Discoverer struct {
 	conn net.PacketConn
+	addr net.Addr
 }
 
-func NewDiscoverer(conn net.PacketConn) *Discoverer {
-	return &Discoverer{conn: conn}
+func NewDiscoverer(conn net.PacketConn, addr net.Addr) *Discoverer {
+	return &Discoverer{conn: conn, addr: addr}
 }
 
-var (
-	discoverAddr = &net.UDPAddr{
-		IP:   net.IPv4(239, 255, 255, 250),
-		Port: 1982,
-	}
-	discoverMsg = []byte(strings.Join([]string{
-		"M-SEARCH * HTTP/1.1",
-		"MAN: \"ssdp:discover\"",
-		"ST: wifi_bulb",
-	}, "\r\n"))
-)
+const DiscoverAddr = "239.255.255.250:1982"
+
+var discoverMsg = []byte(strings.Join([]string{
+	"M-SEARCH * HTTP/1.1",
+	"MAN: \"ssdp:discover\"",
+	"ST: wifi_bulb",
+}, "\r\n"))
 
 func (d *Discoverer) SendDiscover() error {
-	if _, err := d.conn.WriteTo(discoverMsg, discoverAddr); err != nil {
+	if _, err := d.conn.WriteTo(discoverMsg, d.addr); err != nil {
 		return fmt.Errorf("send discover message: %w", err)
 	}
 
@@ -67,5 +65,7 @@ func (d *Discoverer) ReadBulb() (Bulb, error) {
 		}
 	}
 
+	slog.Debug("read bulb response", "id", bulb.ID, "addr", bulb.Addr)
+
 	return bulb, nil
 }
@@ -0,0 +1,43 @@
+package yeelight
+
+import (
+	"errors"
+	"net"
+	"path"
+	"testing"
+
+	"github.com/pugkong/ylc/yeelight/yeelighttest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiscoverer(t *testing.T) {
+	t.Run("it reads recorded bulb responses", func(t *testing.T) {
+		replay, err := yeelighttest.LoadReplay(path.Join("testdata", "synthetic", "discovery.jsonl"))
+		require.NoError(t, err)
+
+		addr, err := net.ResolveUDPAddr("udp", DiscoverAddr)
+		require.NoError(t, err)
+
+		discoverer := NewDiscoverer(replay.PacketConn(), addr)
+		require.NoError(t, discoverer.SendDiscover())
+
+		var bulbs []Bulb
+		for {
+			bulb, err := discoverer.ReadBulb()
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				break
+			}
+			require.NoError(t, err)
+
+			bulbs = append(bulbs, bulb)
+		}
+
+		require.Equal(t, []Bulb{
+			{ID: "0x0000000012345678", Addr: "192.168.1.23:55443"},
+			{ID: "0x0000000087654321", Addr: "192.168.1.7:55443"},
+			{ID: "0x0000000012345678", Addr: "192.168.1.23:55443"},
+		}, bulbs)
+		require.Zero(t, replay.Remaining())
+	})
+}
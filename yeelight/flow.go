@@ -0,0 +1,101 @@
+package yeelight
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+type FlowAction int
+
+const (
+	FlowActionRecover FlowAction = 0
+	FlowActionStay    FlowAction = 1
+	FlowActionOff     FlowAction = 2
+)
+
+type FlowMode int
+
+const (
+	FlowModeRGB         FlowMode = 1
+	FlowModeTemperature FlowMode = 2
+	FlowModeSleep       FlowMode = 7
+)
+
+type FlowTransition struct {
+	Duration int
+	Mode     FlowMode
+	Value    int
+	Bright   int
+}
+
+func flowExpression(transitions []FlowTransition) string {
+	tuples := make([]string, 0, len(transitions))
+	for _, t := range transitions {
+		tuples = append(tuples, fmt.Sprintf("%d,%d,%d,%d", t.Duration, t.Mode, t.Value, t.Bright))
+	}
+
+	return strings.Join(tuples, ",")
+}
+
+func (c *Controller) StartFlow(count int, action FlowAction, transitions []FlowTransition) error {
+	return c.startFlow("start_cf", nil, count, action, transitions)
+}
+
+func (c *Controller) BackgroundStartFlow(count int, action FlowAction, transitions []FlowTransition) error {
+	return c.startFlow("bg_start_cf", nil, count, action, transitions)
+}
+
+// FlowScene turns the light on right into the flow, unlike StartFlow after
+// powering on, which shows the previous state until the flow starts.
+func (c *Controller) FlowScene(count int, action FlowAction, transitions []FlowTransition) error {
+	return c.startFlow("set_scene", []any{"cf"}, count, action, transitions)
+}
+
+func (c *Controller) BackgroundFlowScene(count int, action FlowAction, transitions []FlowTransition) error {
+	return c.startFlow("bg_set_scene", []any{"cf"}, count, action, transitions)
+}
+
+// startFlow checks the transitions and sends them after the leading params.
+// A count of 0 repeats the flow until it is stopped.
+func (c *Controller) startFlow(
+	method string,
+	params []any,
+	count int,
+	action FlowAction,
+	transitions []FlowTransition,
+) error {
+	count, err := c.check(rangeValue{name: "flow count", value: count, min: 0, max: math.MaxInt})
+	if err != nil {
+		return err
+	}
+
+	checked := make([]FlowTransition, 0, len(transitions))
+	for _, t := range transitions {
+		t, err := c.checkTransition(t)
+		if err != nil {
+			return err
+		}
+
+		checked = append(checked, t)
+	}
+
+	_, err = c.sendCommand(command{
+		Method: method,
+		Params: append(params, count*len(checked), action, flowExpression(checked)),
+	})
+
+	return err
+}
+
+func (c *Controller) StopFlow() error {
+	_, err := c.sendCommand(command{Method: "stop_cf", Params: []any{}})
+
+	return err
+}
+
+func (c *Controller) BackgroundStopFlow() error {
+	_, err := c.sendCommand(command{Method: "bg_stop_cf", Params: []any{}})
+
+	return err
+}
@@ -0,0 +1,32 @@
+package yeelight
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestController_StartFlow(t *testing.T) {
+	t.Run("it sends flow expression", func(t *testing.T) {
+		controller := replayController(t, "synthetic/color-flow.jsonl", "192.168.1.23:55443")
+
+		err := controller.StartFlow(2, FlowActionRecover, []FlowTransition{
+			{Duration: 1000, Mode: FlowModeRGB, Value: 0xff0000, Bright: 100},
+			{Duration: 500, Mode: FlowModeSleep},
+		})
+
+		require.NoError(t, err)
+	})
+}
+
+func TestController_FlowScene(t *testing.T) {
+	t.Run("it turns light on into flow", func(t *testing.T) {
+		controller := replayController(t, "synthetic/flow-scene.jsonl", "192.168.1.23:55443")
+
+		err := controller.FlowScene(1, FlowActionStay, []FlowTransition{
+			{Duration: 60000, Mode: FlowModeRGB, Value: 0xff1a00, Bright: 1},
+		})
+
+		require.NoError(t, err)
+	})
+}
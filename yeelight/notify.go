@@ -0,0 +1,59 @@
+package yeelight
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+)
+
+// Watcher reads props notifications, which bulbs send to every connected
+// client when their state changes, no matter who changed it.
+type Watcher struct {
+	reader *bufio.Reader
+}
+
+func NewWatcher(conn TCPConn) *Watcher {
+	return &Watcher{reader: bufio.NewReader(conn)}
+}
+
+type notification struct {
+	Method string                     `json:"method"`
+	Params map[string]json.RawMessage `json:"params"`
+}
+
+// Next blocks until the next props notification and returns the changed
+// props. Bulbs send numbers for some props, they are returned as strings
+// like in the get_prop result.
+func (w *Watcher) Next() (map[string]string, error) {
+	for {
+		line, prefix, err := w.reader.ReadLine()
+		if err != nil {
+			return nil, fmt.Errorf("read notification: %w", err)
+		}
+
+		if prefix {
+			return nil, ErrResponseTooLong
+		}
+
+		var n notification
+		if err := json.Unmarshal(line, &n); err != nil {
+			return nil, fmt.Errorf("parse notification %q: %w", string(line), err)
+		}
+
+		if n.Method != "props" {
+			continue
+		}
+
+		props := make(map[string]string, len(n.Params))
+		for name, raw := range n.Params {
+			var value string
+			if err := json.Unmarshal(raw, &value); err != nil {
+				value = string(raw)
+			}
+
+			props[name] = value
+		}
+
+		return props, nil
+	}
+}
@@ -0,0 +1,40 @@
+package yeelight
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type readConn struct {
+	io.Reader
+}
+
+func (readConn) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+func TestWatcher(t *testing.T) {
+	t.Run("it reads props notifications and skips responses", func(t *testing.T) {
+		watcher := NewWatcher(readConn{strings.NewReader(
+			"{\"id\":1,\"result\":[\"ok\"]}\r\n" +
+				"{\"method\":\"props\",\"params\":{\"power\":\"on\",\"bright\":80,\"ct\":2700}}\r\n",
+		)})
+
+		props, err := watcher.Next()
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"power": "on", "bright": "80", "ct": "2700"}, props)
+
+		_, err = watcher.Next()
+		require.ErrorIs(t, err, io.EOF)
+	})
+
+	t.Run("it handles invalid notification", func(t *testing.T) {
+		watcher := NewWatcher(readConn{strings.NewReader("{\"method\"\r\n")})
+
+		_, err := watcher.Next()
+		require.ErrorContains(t, err, "parse notification")
+	})
+}
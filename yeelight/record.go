@@ -0,0 +1,108 @@
+package yeelight
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+)
+
+type RecordDirection string
+
+const (
+	RecordSend RecordDirection = "send"
+	RecordRecv RecordDirection = "recv"
+)
+
+type RecordEvent struct {
+	Net  string          `json:"net"`
+	Addr string          `json:"addr"`
+	Dir  RecordDirection `json:"dir"`
+	Data string          `json:"data"`
+}
+
+type Recorder struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+}
+
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{encoder: json.NewEncoder(w)}
+}
+
+func (r *Recorder) record(event RecordEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.encoder.Encode(event); err != nil {
+		return fmt.Errorf("record %s %s: %w", event.Net, event.Dir, err)
+	}
+
+	return nil
+}
+
+func (r *Recorder) TCPConn(conn TCPConn, addr string) TCPConn {
+	return &recordTCPConn{conn: conn, recorder: r, addr: addr}
+}
+
+type recordTCPConn struct {
+	conn     TCPConn
+	recorder *Recorder
+	addr     string
+}
+
+func (c *recordTCPConn) Write(b []byte) (int, error) {
+	n, err := c.conn.Write(b)
+	if n > 0 {
+		err = joinRecordErr(err, c.recorder.record(RecordEvent{Net: "tcp", Addr: c.addr, Dir: RecordSend, Data: string(b[:n])}))
+	}
+
+	return n, err
+}
+
+func (c *recordTCPConn) Read(b []byte) (int, error) {
+	n, err := c.conn.Read(b)
+	if n > 0 {
+		err = joinRecordErr(err, c.recorder.record(RecordEvent{Net: "tcp", Addr: c.addr, Dir: RecordRecv, Data: string(b[:n])}))
+	}
+
+	return n, err
+}
+
+func (r *Recorder) PacketConn(conn net.PacketConn) net.PacketConn {
+	return &recordPacketConn{PacketConn: conn, recorder: r}
+}
+
+type recordPacketConn struct {
+	net.PacketConn
+	recorder *Recorder
+}
+
+func (c *recordPacketConn) WriteTo(b []byte, addr net.Addr) (int, error) {
+	n, err := c.PacketConn.WriteTo(b, addr)
+	if n > 0 {
+		err = joinRecordErr(err, c.recorder.record(RecordEvent{Net: "udp", Addr: addr.String(), Dir: RecordSend, Data: string(b[:n])}))
+	}
+
+	return n, err
+}
+
+func (c *recordPacketConn) ReadFrom(b []byte) (int, net.Addr, error) {
+	n, addr, err := c.PacketConn.ReadFrom(b)
+	if n > 0 {
+		err = joinRecordErr(err, c.recorder.record(RecordEvent{Net: "udp", Addr: addr.String(), Dir: RecordRecv, Data: string(b[:n])}))
+	}
+
+	return n, addr, err
+}
+
+// joinRecordErr keeps the connection error, which callers such as discovery
+// inspect for timeouts, and only reports a recording failure on its own.
+func joinRecordErr(connErr, recordErr error) error {
+	if connErr != nil {
+		return connErr
+	}
+
+	return recordErr
+}
@@ -0,0 +1,42 @@
+package yeelight
+
+import (
+	"bytes"
+	"net"
+	"testing"
+
+	"github.com/pugkong/ylc/yeelight/yeelighttest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorder(t *testing.T) {
+	t.Run("it records session which replays", func(t *testing.T) {
+		bulb, err := yeelighttest.NewBulb("0x1")
+		require.NoError(t, err)
+		t.Cleanup(func() { require.NoError(t, bulb.Close()) })
+
+		conn, err := net.Dial("tcp", bulb.Addr())
+		require.NoError(t, err)
+		t.Cleanup(func() { require.NoError(t, conn.Close()) })
+
+		var session bytes.Buffer
+		recorder := NewRecorder(&session)
+
+		controller := NewController(recorder.TCPConn(conn, bulb.Addr()))
+		require.NoError(t, controller.Bright(42, EffectSudden, 0))
+		recorded, err := controller.Info()
+		require.NoError(t, err)
+
+		replay, err := yeelighttest.NewReplay(&session)
+		require.NoError(t, err)
+
+		controller = NewController(replay.TCPConn(bulb.Addr()))
+		require.NoError(t, controller.Bright(42, EffectSudden, 0))
+		replayed, err := controller.Info()
+		require.NoError(t, err)
+
+		require.Equal(t, recorded, replayed)
+		require.Equal(t, "42", replayed.Bright)
+		require.Zero(t, replay.Remaining())
+	})
+}
@@ -0,0 +1,146 @@
+package yeelight
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+const (
+	MinBright         = 1
+	MaxBright         = 100
+	MaxRGB            = 0xffffff
+	MaxHue            = 359
+	MaxSaturation     = 100
+	MaxAdjust         = 100
+	MinSmoothDuration = 30
+	MinFlowDuration   = 50
+
+	// FlowBrightUnchanged as the bright of a flow transition leaves the
+	// brightness as it is.
+	FlowBrightUnchanged = -1
+)
+
+var ErrOutOfRange = errors.New("value out of range")
+
+// RangeError is a value the bulb would reject. Max is math.MaxInt for values
+// with only a lower bound.
+type RangeError struct {
+	Name  string
+	Value int
+	Min   int
+	Max   int
+}
+
+func (e *RangeError) Error() string {
+	if e.Max == math.MaxInt {
+		return fmt.Sprintf("%s: %s %d is less than %d", ErrOutOfRange, e.Name, e.Value, e.Min)
+	}
+
+	return fmt.Sprintf("%s: %s %d is not in %d-%d", ErrOutOfRange, e.Name, e.Value, e.Min, e.Max)
+}
+
+func (e *RangeError) Unwrap() error {
+	return ErrOutOfRange
+}
+
+type rangeValue struct {
+	name  string
+	value int
+	min   int
+	max   int
+}
+
+func brightValue(value int) rangeValue {
+	return rangeValue{name: "bright", value: value, min: MinBright, max: MaxBright}
+}
+
+func flowBrightValue(value int) rangeValue {
+	if value == FlowBrightUnchanged {
+		return rangeValue{name: "bright", value: value, min: value, max: value}
+	}
+
+	return brightValue(value)
+}
+
+func colorTemperatureValue(value int) rangeValue {
+	return rangeValue{name: "color temperature", value: value, min: MinColorTemperature, max: MaxColorTemperature}
+}
+
+func rgbValue(value int) rangeValue {
+	return rangeValue{name: "rgb", value: value, min: 0, max: MaxRGB}
+}
+
+func hueValue(value int) rangeValue {
+	return rangeValue{name: "hue", value: value, min: 0, max: MaxHue}
+}
+
+func saturationValue(value int) rangeValue {
+	return rangeValue{name: "saturation", value: value, min: 0, max: MaxSaturation}
+}
+
+func adjustValue(value int) rangeValue {
+	return rangeValue{name: "percentage", value: value, min: -MaxAdjust, max: MaxAdjust}
+}
+
+// durationValue is the duration of an effect. Bulbs ignore it for sudden
+// changes, but still reject negative ones.
+func durationValue(effect Effect, value int) rangeValue {
+	lower := 0
+	if effect == EffectSmooth {
+		lower = MinSmoothDuration
+	}
+
+	return rangeValue{name: "duration", value: value, min: lower, max: math.MaxInt}
+}
+
+// check returns the value if it is in range. Otherwise it returns the
+// nearest bound in clamp mode or a RangeError.
+func (c *Controller) check(v rangeValue) (int, error) {
+	if v.value >= v.min && v.value <= v.max {
+		return v.value, nil
+	}
+
+	if c.Clamp {
+		return min(max(v.value, v.min), v.max), nil
+	}
+
+	return 0, &RangeError{Name: v.name, Value: v.value, Min: v.min, Max: v.max}
+}
+
+// checkAll checks the values in order and returns them as command params.
+func (c *Controller) checkAll(values ...rangeValue) ([]any, error) {
+	params := make([]any, 0, len(values))
+	for _, v := range values {
+		value, err := c.check(v)
+		if err != nil {
+			return nil, err
+		}
+
+		params = append(params, value)
+	}
+
+	return params, nil
+}
+
+func (c *Controller) checkTransition(t FlowTransition) (FlowTransition, error) {
+	values := []rangeValue{{name: "flow duration", value: t.Duration, min: MinFlowDuration, max: math.MaxInt}}
+	switch t.Mode {
+	case FlowModeRGB:
+		values = append(values, rgbValue(t.Value), flowBrightValue(t.Bright))
+	case FlowModeTemperature:
+		values = append(values, colorTemperatureValue(t.Value), flowBrightValue(t.Bright))
+	}
+
+	params, err := c.checkAll(values...)
+	if err != nil {
+		return FlowTransition{}, err
+	}
+
+	t.Duration = params[0].(int)
+	if len(params) > 1 {
+		t.Value, t.Bright = params[1].(int), params[2].(int)
+	}
+
+	return t, nil
+}
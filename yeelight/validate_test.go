@@ -0,0 +1,165 @@
+package yeelight
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// okConn answers every command with ok and keeps the sent lines.
+type okConn struct {
+	sent      []string
+	responses bytes.Buffer
+}
+
+func (c *okConn) Write(b []byte) (int, error) {
+	var cmd command
+	if err := json.Unmarshal(b, &cmd); err != nil {
+		return 0, err
+	}
+
+	c.sent = append(c.sent, strings.TrimSpace(string(b)))
+	fmt.Fprintf(&c.responses, "{\"id\":%d,\"result\":[\"ok\"]}\r\n", cmd.ID)
+
+	return len(b), nil
+}
+
+func (c *okConn) Read(b []byte) (int, error) {
+	return c.responses.Read(b)
+}
+
+func TestController_validation(t *testing.T) {
+	tests := []struct {
+		name string
+		call func(*Controller) error
+		err  RangeError
+		sent string
+	}{
+		{
+			name: "bright",
+			call: func(c *Controller) error { return c.Bright(500, EffectSmooth, 500) },
+			err:  RangeError{Name: "bright", Value: 500, Min: 1, Max: 100},
+			sent: `{"id":1,"method":"set_bright","params":[100,"smooth",500]}`,
+		},
+		{
+			name: "color temperature",
+			call: func(c *Controller) error { return c.BackgroundColorTemperature(100, EffectSudden, 0) },
+			err:  RangeError{Name: "color temperature", Value: 100, Min: 1700, Max: 6500},
+			sent: `{"id":1,"method":"bg_set_ct_abx","params":[1700,"sudden",0]}`,
+		},
+		{
+			name: "rgb",
+			call: func(c *Controller) error { return c.RGB(0x1000000, EffectSudden, 0) },
+			err:  RangeError{Name: "rgb", Value: 0x1000000, Min: 0, Max: 0xffffff},
+			sent: `{"id":1,"method":"set_rgb","params":[16777215,"sudden",0]}`,
+		},
+		{
+			name: "hue",
+			call: func(c *Controller) error { return c.HSV(360, 50, EffectSudden, 0) },
+			err:  RangeError{Name: "hue", Value: 360, Min: 0, Max: 359},
+			sent: `{"id":1,"method":"set_hsv","params":[359,50,"sudden",0]}`,
+		},
+		{
+			name: "saturation",
+			call: func(c *Controller) error { return c.HSV(120, -5, EffectSudden, 0) },
+			err:  RangeError{Name: "saturation", Value: -5, Min: 0, Max: 100},
+			sent: `{"id":1,"method":"set_hsv","params":[120,0,"sudden",0]}`,
+		},
+		{
+			name: "smooth duration",
+			call: func(c *Controller) error { return c.Power(PowerOn, EffectSmooth, 10, PowerModeNormal) },
+			err:  RangeError{Name: "duration", Value: 10, Min: 30, Max: math.MaxInt},
+			sent: `{"id":1,"method":"set_power","params":["on","smooth",30,0]}`,
+		},
+		{
+			name: "negative duration",
+			call: func(c *Controller) error { return c.AdjustBright(20, -1) },
+			err:  RangeError{Name: "duration", Value: -1, Min: 0, Max: math.MaxInt},
+			sent: `{"id":1,"method":"adjust_bright","params":[20,0]}`,
+		},
+		{
+			name: "flow transition",
+			call: func(c *Controller) error {
+				return c.StartFlow(1, FlowActionStay, []FlowTransition{
+					{Duration: 10, Mode: FlowModeRGB, Value: 0xff0000, Bright: 0},
+				})
+			},
+			err:  RangeError{Name: "flow duration", Value: 10, Min: 50, Max: math.MaxInt},
+			sent: `{"id":1,"method":"start_cf","params":[1,1,"50,1,16711680,1"]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run("it rejects "+tt.name, func(t *testing.T) {
+			conn := &okConn{}
+
+			err := tt.call(NewController(conn))
+			require.ErrorIs(t, err, ErrOutOfRange)
+
+			var rangeErr *RangeError
+			require.ErrorAs(t, err, &rangeErr)
+			require.Equal(t, tt.err, *rangeErr)
+			require.Empty(t, conn.sent)
+		})
+
+		t.Run("it clamps "+tt.name, func(t *testing.T) {
+			conn := &okConn{}
+			controller := NewController(conn)
+			controller.Clamp = true
+
+			require.NoError(t, tt.call(controller))
+			require.Equal(t, []string{tt.sent}, conn.sent)
+		})
+	}
+
+	t.Run("it sends values in range", func(t *testing.T) {
+		conn := &okConn{}
+
+		require.NoError(t, NewController(conn).Bright(1, EffectSudden, 0))
+		require.Equal(t, []string{`{"id":1,"method":"set_bright","params":[1,"sudden",0]}`}, conn.sent)
+	})
+
+	for _, clamp := range []bool{false, true} {
+		t.Run(fmt.Sprintf("it keeps flow bright unchanged with clamp %t", clamp), func(t *testing.T) {
+			conn := &okConn{}
+			controller := NewController(conn)
+			controller.Clamp = clamp
+
+			err := controller.StartFlow(1, FlowActionStay, []FlowTransition{
+				{Duration: 500, Mode: FlowModeTemperature, Value: 2700, Bright: FlowBrightUnchanged},
+			})
+			require.NoError(t, err)
+			require.Equal(t, []string{`{"id":1,"method":"start_cf","params":[1,1,"500,2,2700,-1"]}`}, conn.sent)
+		})
+	}
+
+	t.Run("it rejects other negative flow bright", func(t *testing.T) {
+		conn := &okConn{}
+
+		err := NewController(conn).StartFlow(1, FlowActionStay, []FlowTransition{
+			{Duration: 500, Mode: FlowModeRGB, Value: 0xff0000, Bright: -2},
+		})
+
+		var rangeErr *RangeError
+		require.ErrorAs(t, err, &rangeErr)
+		require.Equal(t, RangeError{Name: "bright", Value: -2, Min: 1, Max: 100}, *rangeErr)
+		require.Empty(t, conn.sent)
+	})
+}
+
+func TestRangeError(t *testing.T) {
+	t.Run("it describes bounded value", func(t *testing.T) {
+		err := &RangeError{Name: "bright", Value: 500, Min: 1, Max: 100}
+		require.EqualError(t, err, "value out of range: bright 500 is not in 1-100")
+	})
+
+	t.Run("it describes value with lower bound", func(t *testing.T) {
+		err := &RangeError{Name: "duration", Value: 10, Min: 30, Max: math.MaxInt}
+		require.EqualError(t, err, "value out of range: duration 10 is less than 30")
+	})
+}
@@ -0,0 +1,522 @@
+package yeelighttest
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"strconv"
+	"sync"
+)
+
+type Bulb struct {
+	ID string
+
+	listener net.Listener
+	wg       sync.WaitGroup
+
+	mu       sync.Mutex
+	props    map[string]string
+	methods  []string
+	errors   map[string]bulbError
+	drops    int
+	cuts     int
+	conns    map[net.Conn]struct{}
+	accepted int
+}
+
+func NewBulb(id string) (*Bulb, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("listen tcp: %w", err)
+	}
+
+	bulb := &Bulb{
+		ID:       id,
+		listener: listener,
+		props: map[string]string{
+			"power":      "on",
+			"bright":     "100",
+			"color_mode": "2",
+			"ct":         "4000",
+			"rgb":        "16777215",
+			"hue":        "0",
+			"sat":        "0",
+		},
+		errors: make(map[string]bulbError),
+		conns:  make(map[net.Conn]struct{}),
+	}
+
+	bulb.wg.Add(1)
+	go bulb.serve()
+
+	return bulb, nil
+}
+
+func (b *Bulb) Addr() string {
+	return b.listener.Addr().String()
+}
+
+func (b *Bulb) Prop(name string) string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.props[name]
+}
+
+func (b *Bulb) SetProp(name, value string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.props[name] = value
+}
+
+func (b *Bulb) Props() map[string]string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return maps.Clone(b.props)
+}
+
+func (b *Bulb) SetError(method string, code int, message string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.errors[method] = bulbError{Code: code, Message: message}
+}
+
+// DropCommands makes the bulb close the connection instead of executing the
+// next n commands, the way a bulb on a flaky network looks to the client.
+func (b *Bulb) DropCommands(n int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.drops = n
+}
+
+func (b *Bulb) drop() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.drops == 0 {
+		return false
+	}
+	b.drops--
+
+	return true
+}
+
+// CutResponses makes the bulb execute the next n commands but send only the
+// first half of their responses before closing the connection.
+func (b *Bulb) CutResponses(n int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.cuts = n
+}
+
+func (b *Bulb) cut() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.cuts == 0 {
+		return false
+	}
+	b.cuts--
+
+	return true
+}
+
+func (b *Bulb) Methods() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return append([]string(nil), b.methods...)
+}
+
+// Connections returns the number of open client connections.
+func (b *Bulb) Connections() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return len(b.conns)
+}
+
+// Accepted returns the number of connections accepted so far.
+func (b *Bulb) Accepted() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.accepted
+}
+
+// CloseConnections closes the open client connections, the way bulbs drop
+// idle ones, and keeps accepting new ones.
+func (b *Bulb) CloseConnections() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var err error
+	for conn := range b.conns {
+		err = errors.Join(err, conn.Close())
+	}
+
+	return err
+}
+
+func (b *Bulb) Close() error {
+	err := b.listener.Close()
+
+	b.mu.Lock()
+	for conn := range b.conns {
+		err = errors.Join(err, conn.Close())
+	}
+	b.mu.Unlock()
+
+	b.wg.Wait()
+
+	return err
+}
+
+func (b *Bulb) serve() {
+	defer b.wg.Done()
+
+	for {
+		conn, err := b.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		b.mu.Lock()
+		b.conns[conn] = struct{}{}
+		b.accepted++
+		b.mu.Unlock()
+
+		b.wg.Add(1)
+		go b.handle(conn)
+	}
+}
+
+type command struct {
+	ID     int    `json:"id"`
+	Method string `json:"method"`
+	Params []any  `json:"params"`
+}
+
+type bulbError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type response struct {
+	ID     int        `json:"id"`
+	Result any        `json:"result,omitempty"`
+	Error  *bulbError `json:"error,omitempty"`
+}
+
+type cron struct {
+	Type  int `json:"type"`
+	Delay int `json:"delay"`
+	Mix   int `json:"mix"`
+}
+
+type notification struct {
+	Method string            `json:"method"`
+	Params map[string]string `json:"params"`
+}
+
+func (b *Bulb) handle(conn net.Conn) {
+	defer b.wg.Done()
+	defer func() {
+		b.mu.Lock()
+		delete(b.conns, conn)
+		b.mu.Unlock()
+
+		conn.Close()
+	}()
+
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		var cmd command
+		if err := json.Unmarshal(scanner.Bytes(), &cmd); err != nil {
+			return
+		}
+
+		if b.drop() {
+			return
+		}
+
+		result, changed, bulbErr := b.execute(cmd)
+
+		if len(changed) > 0 {
+			b.notify(notification{Method: "props", Params: changed})
+		}
+
+		if b.cut() {
+			_ = writeHalfLine(conn, response{ID: cmd.ID, Result: result, Error: bulbErr})
+
+			return
+		}
+
+		if err := writeLine(conn, response{ID: cmd.ID, Result: result, Error: bulbErr}); err != nil {
+			return
+		}
+	}
+}
+
+// notify sends the notification to every connection, like bulbs do.
+func (b *Bulb) notify(n notification) {
+	b.mu.Lock()
+	conns := make([]net.Conn, 0, len(b.conns))
+	for conn := range b.conns {
+		conns = append(conns, conn)
+	}
+	b.mu.Unlock()
+
+	for _, conn := range conns {
+		_ = writeLine(conn, n)
+	}
+}
+
+func writeLine(conn net.Conn, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	_, err = conn.Write(append(data, '\r', '\n'))
+
+	return err
+}
+
+func writeHalfLine(conn net.Conn, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	_, err = conn.Write(data[:len(data)/2])
+
+	return err
+}
+
+var okResult = []string{"ok"}
+
+func (b *Bulb) execute(cmd command) (any, map[string]string, *bulbError) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.methods = append(b.methods, cmd.Method)
+
+	if bulbErr, ok := b.errors[cmd.Method]; ok {
+		return nil, nil, &bulbErr
+	}
+
+	switch cmd.Method {
+	case "get_prop":
+		result := make([]string, 0, len(cmd.Params))
+		for _, param := range cmd.Params {
+			name, _ := param.(string)
+			result = append(result, b.props[name])
+		}
+
+		return result, nil, nil
+	case "toggle":
+		return okResult, b.set("power", toggled(b.props["power"])), nil
+	case "dev_toggle":
+		changed := b.set("power", toggled(b.props["power"]))
+		if power, ok := b.props["bg_power"]; ok {
+			b.props["bg_power"] = toggled(power)
+			changed["bg_power"] = b.props["bg_power"]
+		}
+
+		return okResult, changed, nil
+	case "bg_toggle":
+		return okResult, b.set("bg_power", toggled(b.props["bg_power"])), nil
+	case "set_power":
+		return b.setPower(cmd)
+	case "bg_set_power":
+		return b.setParams(cmd, "bg_power")
+	case "set_bright":
+		if b.props["active_mode"] == "1" {
+			return b.setParams(cmd, "nl_br")
+		}
+
+		return b.setParams(cmd, "bright")
+	case "bg_set_bright":
+		return b.setParams(cmd, "bg_bright")
+	case "set_ct_abx":
+		return b.setMode(cmd, "color_mode", "2", "ct")
+	case "bg_set_ct_abx":
+		return b.setMode(cmd, "bg_lmode", "2", "bg_ct")
+	case "set_rgb":
+		return b.setMode(cmd, "color_mode", "1", "rgb")
+	case "bg_set_rgb":
+		return b.setMode(cmd, "bg_lmode", "1", "bg_rgb")
+	case "set_hsv":
+		return b.setMode(cmd, "color_mode", "3", "hue", "sat")
+	case "bg_set_hsv":
+		return b.setMode(cmd, "bg_lmode", "3", "bg_hue", "bg_sat")
+	case "set_default", "bg_set_default":
+		return okResult, nil, nil
+	case "cron_add":
+		if len(cmd.Params) < 2 {
+			return nil, nil, &bulbError{Code: -1, Message: "invalid params"}
+		}
+
+		return okResult, b.set("delayoff", paramString(cmd.Params[1])), nil
+	case "cron_get":
+		delay, _ := strconv.Atoi(b.props["delayoff"])
+		if delay == 0 {
+			return []cron{}, nil, nil
+		}
+
+		return []cron{{Type: 0, Delay: delay}}, nil, nil
+	case "cron_del":
+		return okResult, b.set("delayoff", "0"), nil
+	case "start_cf":
+		return b.startFlow(cmd, "flowing", "flow_params")
+	case "bg_start_cf":
+		return b.startFlow(cmd, "bg_flowing", "bg_flow_params")
+	case "set_scene":
+		return b.flowScene(cmd, "power", "flowing", "flow_params")
+	case "bg_set_scene":
+		return b.flowScene(cmd, "bg_power", "bg_flowing", "bg_flow_params")
+	case "adjust_bright":
+		return b.adjust(cmd, "bright", 1, 100, 100)
+	case "bg_adjust_bright":
+		return b.adjust(cmd, "bg_bright", 1, 100, 100)
+	case "adjust_ct":
+		return b.adjust(cmd, "ct", 1700, 6500, 6500-1700)
+	case "bg_adjust_ct":
+		return b.adjust(cmd, "bg_ct", 1700, 6500, 6500-1700)
+	}
+
+	return nil, nil, &bulbError{Code: -1, Message: "method not supported"}
+}
+
+func (b *Bulb) set(name, value string) map[string]string {
+	b.props[name] = value
+
+	return map[string]string{name: value}
+}
+
+// startFlow keeps the flow expression in the flow params prop, the flow
+// itself is not played.
+func (b *Bulb) startFlow(cmd command, flowing, params string) (any, map[string]string, *bulbError) {
+	if len(cmd.Params) != 3 {
+		return nil, nil, &bulbError{Code: -1, Message: "invalid params"}
+	}
+
+	changed := b.set(flowing, "1")
+	b.props[params] = fmt.Sprintf("%s,%s,%s",
+		paramString(cmd.Params[0]), paramString(cmd.Params[1]), paramString(cmd.Params[2]))
+
+	return okResult, changed, nil
+}
+
+// flowScene supports only the color flow scene, which turns the light on and
+// starts the flow.
+func (b *Bulb) flowScene(cmd command, power, flowing, params string) (any, map[string]string, *bulbError) {
+	if len(cmd.Params) == 0 || cmd.Params[0] != "cf" {
+		return nil, nil, &bulbError{Code: -1, Message: "invalid params"}
+	}
+
+	result, changed, bulbErr := b.startFlow(command{Method: cmd.Method, Params: cmd.Params[1:]}, flowing, params)
+	if bulbErr != nil {
+		return nil, nil, bulbErr
+	}
+
+	b.props[power] = "on"
+	changed[power] = "on"
+
+	return result, changed, nil
+}
+
+func (b *Bulb) setPower(cmd command) (any, map[string]string, *bulbError) {
+	result, changed, bulbErr := b.setParams(cmd, "power")
+	if bulbErr != nil {
+		return nil, nil, bulbErr
+	}
+
+	_, nightLight := b.props["active_mode"]
+	if !nightLight || len(cmd.Params) < 4 {
+		return result, changed, nil
+	}
+
+	switch paramString(cmd.Params[3]) {
+	case "5":
+		changed["active_mode"] = "1"
+	case "1", "2", "3":
+		changed["active_mode"] = "0"
+	default:
+		return result, changed, nil
+	}
+	b.props["active_mode"] = changed["active_mode"]
+
+	return result, changed, nil
+}
+
+func (b *Bulb) setParams(cmd command, names ...string) ([]string, map[string]string, *bulbError) {
+	if len(cmd.Params) < len(names) {
+		return nil, nil, &bulbError{Code: -1, Message: "invalid params"}
+	}
+
+	changed := make(map[string]string, len(names))
+	for i, name := range names {
+		value := paramString(cmd.Params[i])
+		b.props[name] = value
+		changed[name] = value
+	}
+
+	return okResult, changed, nil
+}
+
+func (b *Bulb) setMode(cmd command, modeName, mode string, names ...string) ([]string, map[string]string, *bulbError) {
+	result, changed, bulbErr := b.setParams(cmd, names...)
+	if bulbErr != nil {
+		return nil, nil, bulbErr
+	}
+
+	b.props[modeName] = mode
+	changed[modeName] = mode
+
+	return result, changed, nil
+}
+
+func (b *Bulb) adjust(cmd command, name string, low, high, scale int) ([]string, map[string]string, *bulbError) {
+	if len(cmd.Params) < 1 {
+		return nil, nil, &bulbError{Code: -1, Message: "invalid params"}
+	}
+
+	percentage, ok := cmd.Params[0].(float64)
+	if !ok {
+		return nil, nil, &bulbError{Code: -1, Message: "invalid params"}
+	}
+
+	value, _ := strconv.Atoi(b.props[name])
+	value = min(max(value+int(percentage)*scale/100, low), high)
+
+	return okResult, b.set(name, strconv.Itoa(value)), nil
+}
+
+func paramString(param any) string {
+	switch v := param.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func toggled(power string) string {
+	if power == "on" {
+		return "off"
+	}
+
+	return "on"
+}
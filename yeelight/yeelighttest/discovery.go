@@ -0,0 +1,86 @@
+package yeelighttest
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+)
+
+type Discovery struct {
+	conn  net.PacketConn
+	bulbs []*Bulb
+	wg    sync.WaitGroup
+}
+
+func NewDiscovery(bulbs ...*Bulb) (*Discovery, error) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("listen udp: %w", err)
+	}
+
+	discovery := &Discovery{conn: conn, bulbs: bulbs}
+
+	discovery.wg.Add(1)
+	go discovery.serve()
+
+	return discovery, nil
+}
+
+func (d *Discovery) Addr() string {
+	return d.conn.LocalAddr().String()
+}
+
+func (d *Discovery) Close() error {
+	err := d.conn.Close()
+	d.wg.Wait()
+
+	return err
+}
+
+var searchPrefix = []byte("M-SEARCH * HTTP/1.1")
+
+func (d *Discovery) serve() {
+	defer d.wg.Done()
+
+	buffer := make([]byte, 4096)
+	for {
+		n, addr, err := d.conn.ReadFrom(buffer)
+		if err != nil {
+			return
+		}
+
+		if !bytes.HasPrefix(buffer[:n], searchPrefix) {
+			continue
+		}
+
+		for _, bulb := range d.bulbs {
+			if _, err := d.conn.WriteTo(bulb.advertisement(), addr); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (b *Bulb) advertisement() []byte {
+	props := b.Props()
+
+	return []byte(strings.Join([]string{
+		"HTTP/1.1 200 OK",
+		"Cache-Control: max-age=3600",
+		"Location: yeelight://" + b.Addr(),
+		"Server: POSIX UPnP/1.0 YGLC/1",
+		"id: " + b.ID,
+		"model: color",
+		"fw_ver: 18",
+		"power: " + props["power"],
+		"bright: " + props["bright"],
+		"color_mode: " + props["color_mode"],
+		"ct: " + props["ct"],
+		"rgb: " + props["rgb"],
+		"hue: " + props["hue"],
+		"sat: " + props["sat"],
+		"",
+	}, "\r\n"))
+}
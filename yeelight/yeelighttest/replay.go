@@ -0,0 +1,211 @@
+package yeelighttest
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+type replayEvent struct {
+	Net  string `json:"net"`
+	Addr string `json:"addr"`
+	Dir  string `json:"dir"`
+	Data string `json:"data"`
+}
+
+// Replay plays back a session written by yeelight.Recorder. Every connection
+// replays the events of its own address in order and fails on any write that
+// differs from the recording.
+type Replay struct {
+	mu     sync.Mutex
+	events map[string][]replayEvent
+}
+
+var ErrReplayMismatch = errors.New("replay mismatch")
+
+func NewReplay(r io.Reader) (*Replay, error) {
+	replay := &Replay{events: make(map[string][]replayEvent)}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var event replayEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			return nil, fmt.Errorf("decode replay event: %w", err)
+		}
+
+		key := replayKey(event)
+		replay.events[key] = append(replay.events[key], event)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read replay: %w", err)
+	}
+
+	return replay, nil
+}
+
+func LoadReplay(path string) (*Replay, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open replay: %w", err)
+	}
+	defer file.Close()
+
+	return NewReplay(file)
+}
+
+func replayKey(event replayEvent) string {
+	if event.Net == "udp" {
+		return "udp"
+	}
+
+	return "tcp " + event.Addr
+}
+
+func (r *Replay) next(key, dir string) (replayEvent, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	events := r.events[key]
+	if len(events) == 0 || events[0].Dir != dir {
+		return replayEvent{}, false
+	}
+
+	r.events[key] = events[1:]
+
+	return events[0], true
+}
+
+// Remaining returns the number of events which were not replayed yet.
+func (r *Replay) Remaining() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	remaining := 0
+	for _, events := range r.events {
+		remaining += len(events)
+	}
+
+	return remaining
+}
+
+func (r *Replay) TCPConn(addr string) *ReplayTCPConn {
+	return &ReplayTCPConn{replay: r, key: "tcp " + addr}
+}
+
+type ReplayTCPConn struct {
+	replay *Replay
+	key    string
+}
+
+func (c *ReplayTCPConn) Write(b []byte) (int, error) {
+	event, ok := c.replay.next(c.key, "send")
+	if !ok {
+		return 0, fmt.Errorf("%w: unexpected write %q", ErrReplayMismatch, b)
+	}
+
+	if event.Data != string(b) {
+		return 0, fmt.Errorf("%w: expected write %q, got %q", ErrReplayMismatch, event.Data, b)
+	}
+
+	return len(b), nil
+}
+
+func (c *ReplayTCPConn) Read(b []byte) (int, error) {
+	event, ok := c.replay.next(c.key, "recv")
+	if !ok {
+		return 0, io.EOF
+	}
+
+	if len(event.Data) > len(b) {
+		return 0, fmt.Errorf("%w: read buffer is too small", ErrReplayMismatch)
+	}
+
+	return copy(b, event.Data), nil
+}
+
+func (c *ReplayTCPConn) Close() error {
+	return nil
+}
+
+func (r *Replay) PacketConn() *ReplayPacketConn {
+	return &ReplayPacketConn{replay: r}
+}
+
+type ReplayPacketConn struct {
+	replay *Replay
+}
+
+func (c *ReplayPacketConn) WriteTo(b []byte, addr net.Addr) (int, error) {
+	event, ok := c.replay.next("udp", "send")
+	if !ok {
+		return 0, fmt.Errorf("%w: unexpected datagram to %s", ErrReplayMismatch, addr)
+	}
+
+	if event.Data != string(b) || event.Addr != addr.String() {
+		return 0, fmt.Errorf("%w: expected datagram to %s, got to %s", ErrReplayMismatch, event.Addr, addr)
+	}
+
+	return len(b), nil
+}
+
+// ReadFrom returns a timeout error when the recorded datagrams run out, the
+// same way a discovery deadline ends a real session.
+func (c *ReplayPacketConn) ReadFrom(b []byte) (int, net.Addr, error) {
+	event, ok := c.replay.next("udp", "recv")
+	if !ok {
+		return 0, nil, timeoutError{}
+	}
+
+	addr, err := net.ResolveUDPAddr("udp", event.Addr)
+	if err != nil {
+		return 0, nil, fmt.Errorf("resolve replay addr: %w", err)
+	}
+
+	return copy(b, event.Data), addr, nil
+}
+
+func (c *ReplayPacketConn) Close() error {
+	return nil
+}
+
+func (c *ReplayPacketConn) LocalAddr() net.Addr {
+	return &net.UDPAddr{IP: net.IPv4zero}
+}
+
+func (c *ReplayPacketConn) SetDeadline(time.Time) error {
+	return nil
+}
+
+func (c *ReplayPacketConn) SetReadDeadline(time.Time) error {
+	return nil
+}
+
+func (c *ReplayPacketConn) SetWriteDeadline(time.Time) error {
+	return nil
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string {
+	return "i/o timeout"
+}
+
+func (timeoutError) Timeout() bool {
+	return true
+}
+
+func (timeoutError) Temporary() bool {
+	return true
+}